@@ -0,0 +1,210 @@
+package hsleaderboards
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Archive keeps the raw body of every fetched page
+// bodies are gzipped and stored by their sha256, so identical pages
+// are stored once, and an index per game mode lists every fetch
+type Archive struct {
+	Dir string
+	mu  sync.Mutex
+}
+
+// ArchivedPage is a single fetched page in the index
+type ArchivedPage struct {
+	Region    string `json:"region"`
+	Season    int    `json:"season"`
+	Page      int    `json:"page"`
+	Timestamp int64  `json:"timestamp"`
+	Hash      string `json:"hash"`
+}
+
+// ArchivedScrape is every page of a region fetched in one scrape
+type ArchivedScrape struct {
+	Region    string
+	Season    int
+	Timestamp int64
+	Pages     []ArchivedPage
+}
+
+func MakeArchive(dir string) (*Archive, error) {
+	for _, sub := range []string{"objects", "index"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return &Archive{Dir: dir}, nil
+}
+
+// Save stores the body of a page and adds it to the index of the mode
+func (a *Archive) Save(mode, region string, season, page int, t int64, body []byte) error {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	if err := a.saveObject(hash, body); err != nil {
+		return err
+	}
+	line, err := json.Marshal(&ArchivedPage{Region: region, Season: season, Page: page, Timestamp: t, Hash: hash})
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	f, err := os.OpenFile(a.indexPath(mode), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// saveObject writes a gzipped body unless it is already stored
+// the file is renamed into place so a crash never leaves half an object
+func (a *Archive) saveObject(hash string, body []byte) error {
+	path := a.objectPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), hash+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the body of an archived page
+func (a *Archive) Load(p *ArchivedPage) ([]byte, error) {
+	if len(p.Hash) != sha256.Size*2 {
+		return nil, fmt.Errorf("invalid hash %q", p.Hash)
+	}
+	f, err := os.Open(a.objectPath(p.Hash))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return ioutil.ReadAll(zr)
+}
+
+// Scrapes lists the archived scrapes of a mode, oldest first
+// pages fetched more than once in a scrape are only listed once
+func (a *Archive) Scrapes(mode string) ([]ArchivedScrape, error) {
+	f, err := os.Open(a.indexPath(mode))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var scrapes = make([]ArchivedScrape, 0)
+	var index = make(map[string]int)
+	var scanner = bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var page ArchivedPage
+		if err := json.Unmarshal(scanner.Bytes(), &page); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", a.indexPath(mode), line, err)
+		}
+		key := fmt.Sprintf("%s|%d|%d", page.Region, page.Season, page.Timestamp)
+		i, ok := index[key]
+		if !ok {
+			i = len(scrapes)
+			index[key] = i
+			scrapes = append(scrapes, ArchivedScrape{Region: page.Region, Season: page.Season, Timestamp: page.Timestamp})
+		}
+		scrapes[i].Pages = append(scrapes[i].Pages, page)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for i := range scrapes {
+		scrapes[i].Pages = uniquePages(scrapes[i].Pages)
+	}
+	sort.SliceStable(scrapes, func(i, j int) bool {
+		if scrapes[i].Timestamp != scrapes[j].Timestamp {
+			return scrapes[i].Timestamp < scrapes[j].Timestamp
+		}
+		return scrapes[i].Region < scrapes[j].Region
+	})
+	return scrapes, nil
+}
+
+// Complete checks if the scrape holds every page from 1 to total
+func (s *ArchivedScrape) Complete(total int) bool {
+	if total == 0 {
+		total = 1
+	}
+	if len(s.Pages) != total {
+		return false
+	}
+	for i, page := range s.Pages {
+		if page.Page != i+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// uniquePages sorts pages by number, keeping the last fetch of each
+func uniquePages(pages []ArchivedPage) []ArchivedPage {
+	var unique = make([]ArchivedPage, 0, len(pages))
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].Page < pages[j].Page
+	})
+	for _, page := range pages {
+		if n := len(unique); n > 0 && unique[n-1].Page == page.Page {
+			unique[n-1] = page
+			continue
+		}
+		unique = append(unique, page)
+	}
+	return unique
+}
+
+func (a *Archive) objectPath(hash string) string {
+	return filepath.Join(a.Dir, "objects", hash[:2], hash[2:]+".json.gz")
+}
+
+func (a *Archive) indexPath(mode string) string {
+	return filepath.Join(a.Dir, "index", strings.ToLower(mode)+".jsonl")
+}
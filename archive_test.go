@@ -0,0 +1,140 @@
+package hsleaderboards_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	hs "hsleaderboards"
+	"hsleaderboards/hstest"
+)
+
+// allPoints returns every stored point of a table
+// ordered by region, name and first seen, as regions are saved in parallel
+func allPoints(t *testing.T, db hs.Storage, table string) []hs.Point {
+	t.Helper()
+	var points = make([]hs.Point, 0)
+	err := db.Points(table, hs.PointFilter{}, func(p *hs.Point) error {
+		points = append(points, *p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(points, func(i, j int) bool {
+		a, b := points[i], points[j]
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.FirstSeen < b.FirstSeen
+	})
+	return points
+}
+
+// replay replays the archive of a mode into a fresh database
+func replay(t *testing.T, archive *hs.Archive, mode hs.Mode) *hs.SQLStorage {
+	t.Helper()
+	logger := log.New(ioutil.Discard, "", 0)
+	cfg := &hs.Config{Interval: 600, Concurrency: 2}
+	db := openStorage(t, logger, cfg)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	sc := hs.MakeScraper(db, logger, cfg)
+	sc.Archive = archive
+	if err := hs.MakeLeaderboard(mode, nil).Replay(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// nextSecond waits until the unix second changes, scrapes are archived by second
+func nextSecond() {
+	now := time.Now()
+	time.Sleep(now.Truncate(time.Second).Add(time.Second).Sub(now))
+}
+
+func TestReplayRebuildsScrapedPoints(t *testing.T) {
+	srv, db, sc := setup(t)
+	archive, err := hs.MakeArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc.Archive = archive
+	srv.Seasons("STD", 105)
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US", "EU"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	for i, order := range [][]string{{"a", "b", "c"}, {"a", "b", "c"}, {"b", "a", "c"}} {
+		if i > 0 {
+			nextSecond()
+		}
+		for _, region := range site.Regions {
+			var rows []hstest.Row
+			for rank, name := range order {
+				rows = append(rows, hstest.Row{Name: name, Rank: rank + 1})
+			}
+			srv.Set("STD", region, 105, rows...)
+		}
+		if err := site.Scrape(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := allPoints(t, db, "standard")
+	if len(want) != 10 {
+		t.Fatalf("scraped %d points, want 10", len(want))
+	}
+	if got := allPoints(t, replay(t, archive, hs.Standard), "standard"); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed %v, want %v", got, want)
+	}
+}
+
+func TestReplaySkipsIncompleteScrapes(t *testing.T) {
+	srv, _, _ := setup(t)
+	archive, err := hs.MakeArchive(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1}, hstest.Row{Name: "b", Rank: 2}, hstest.Row{Name: "c", Rank: 3})
+	var pages [][]byte
+	for page := 1; page <= 2; page++ {
+		url := hs.DefaultBaseURL + fmt.Sprintf(hs.Standard.Path, "US", 105, page)
+		body, err := srv.Fetcher().Fetch(context.Background(), url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, body)
+	}
+	// The first scrape lost its second page, the second fetched its first page twice
+	for _, saved := range []struct {
+		page int
+		t    int64
+	}{{1, 100}, {1, 200}, {2, 200}, {1, 200}} {
+		if err := archive.Save("Standard", "US", 105, saved.page, saved.t, pages[saved.page-1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	points := allPoints(t, replay(t, archive, hs.Standard), "standard")
+	if len(points) != 3 {
+		t.Fatalf("replayed %d points, want 3", len(points))
+	}
+	for _, p := range points {
+		if p.FirstSeen != 200 || p.Timestamp != 200 {
+			t.Errorf("%s seen from %d to %d, want only the complete scrape at 200", p.Name, p.FirstSeen, p.Timestamp)
+		}
+	}
+}
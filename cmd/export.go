@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	hs "hsleaderboards"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// row is a point of a game mode as it is exported and served
+// modes without rating or score leave them out
+type row struct {
+	Mode       string   `json:"mode"`
+	Season     int      `json:"season"`
+	Region     string   `json:"region"`
+	Name       string   `json:"name"`
+	Rank       int      `json:"rank"`
+	Rating     *int     `json:"rating,omitempty"`
+	Score      *float64 `json:"score,omitempty"`
+	FirstSeen  int64    `json:"first_seen"`
+	LastSeen   int64    `json:"last_seen"`
+	Confidence float64  `json:"confidence"`
+}
+
+var csvHeader = []string{"mode", "season", "region", "name", "rank", "rating", "score", "first_seen", "last_seen", "confidence"}
+
+func makeRow(mode hs.Mode, p *hs.Point) *row {
+	var r = &row{
+		Mode:       mode.Name,
+		Season:     p.Season,
+		Region:     p.Region,
+		Name:       p.Name,
+		Rank:       p.Rank,
+		FirstSeen:  p.FirstSeen,
+		LastSeen:   p.Timestamp,
+		Confidence: p.Confidence,
+	}
+	if mode.Rated {
+		rating := p.Rating
+		r.Rating = &rating
+	}
+	if mode.Scored {
+		score := p.Score
+		r.Score = &score
+	}
+	return r
+}
+
+// record converts a row to the columns of csvHeader
+func (r *row) record() []string {
+	var rating, score string
+	if r.Rating != nil {
+		rating = strconv.Itoa(*r.Rating)
+	}
+	if r.Score != nil {
+		score = strconv.FormatFloat(*r.Score, 'f', -1, 64)
+	}
+	return []string{
+		r.Mode,
+		strconv.Itoa(r.Season),
+		r.Region,
+		r.Name,
+		strconv.Itoa(r.Rank),
+		rating,
+		score,
+		strconv.FormatInt(r.FirstSeen, 10),
+		strconv.FormatInt(r.LastSeen, 10),
+		strconv.FormatFloat(r.Confidence, 'f', -1, 64),
+	}
+}
+
+// rowWriter writes rows in an export format
+type rowWriter interface {
+	Write(r *row) error
+	Flush() error
+}
+
+type csvRows struct {
+	w *csv.Writer
+}
+
+func (c *csvRows) Write(r *row) error {
+	return c.w.Write(r.record())
+}
+
+func (c *csvRows) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonRows struct {
+	e *json.Encoder
+}
+
+func (j *jsonRows) Write(r *row) error {
+	return j.e.Encode(r)
+}
+
+func (j *jsonRows) Flush() error {
+	return nil
+}
+
+// makeRowWriter makes a writer for the csv or jsonl format
+func makeRowWriter(out io.Writer, format string) (rowWriter, error) {
+	switch format {
+	case "csv":
+		w := csv.NewWriter(out)
+		return &csvRows{w: w}, w.Write(csvHeader)
+	case "jsonl":
+		return &jsonRows{e: json.NewEncoder(out)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected csv or jsonl", format)
+	}
+}
+
+// eachPoint calls fn with the points of every selected mode and region
+func eachPoint(cfg *hs.Config, db hs.Storage, filter hs.PointFilter, fn func(hs.Mode, *hs.Point) error) error {
+	for _, mode := range selectedModes(cfg) {
+		for _, region := range selectedRegions(cfg, mode) {
+			filter.Region = region
+			err := db.Points(mode.Table, filter, func(p *hs.Point) error {
+				return fn(mode, p)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", mode.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// exportCommand writes the stored points of the selected modes and regions
+func exportCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	season := fs.Int("season", 0, "season to export, every season if 0")
+	format := fs.String("format", "csv", "output format, csv or jsonl")
+	output := fs.String("o", "", "output file, stdout if empty")
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	requireSchema(l, db)
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			l.Fatalf("Failed creating output, %s", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	w, err := makeRowWriter(out, *format)
+	if err != nil {
+		l.Fatalf("Failed exporting, %s", err)
+	}
+	err = eachPoint(cfg, db, hs.PointFilter{Season: *season}, func(mode hs.Mode, p *hs.Point) error {
+		return w.Write(makeRow(mode, p))
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		l.Fatalf("Failed exporting, %s", err)
+	}
+}
+
+// queryCommand prints the history of a player in the selected modes and regions
+func queryCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	season := fs.Int("season", 0, "season to search, every season if 0")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	cfg, db := open(l, g)
+	defer db.Close()
+	requireSchema(l, db)
+
+	var found int
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tSEASON\tREGION\tNAME\tRANK\tRATING\tFIRST SEEN\tLAST SEEN\tCONFIDENCE")
+	err := eachPoint(cfg, db, hs.PointFilter{Season: *season, Name: fs.Arg(0)}, func(mode hs.Mode, p *hs.Point) error {
+		var rating = "-"
+		if mode.Rated {
+			rating = strconv.Itoa(p.Rating)
+		} else if mode.Scored {
+			rating = strconv.FormatFloat(p.Score, 'f', -1, 64)
+		}
+		found++
+		_, err := fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%s\t%s\t%s\t%.2f\n",
+			mode.Name, p.Season, p.Region, p.Name, p.Rank, rating, formatTime(p.FirstSeen), formatTime(p.Timestamp), p.Confidence)
+		return err
+	})
+	if err != nil {
+		l.Fatalf("Failed querying, %s", err)
+	}
+	if found == 0 {
+		l.Fatalf("No points found for %q", fs.Arg(0))
+	}
+	tw.Flush()
+}
+
+// statsCommand prints a summary of the points of the selected modes and regions
+func statsCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	requireSchema(l, db)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "MODE\tSEASON\tREGION\tPOINTS\tPLAYERS\tFIRST SEEN\tLAST SEEN")
+	for _, mode := range selectedModes(cfg) {
+		stats, err := db.Stats(mode.Table)
+		if err != nil {
+			l.Fatalf("Failed reading stats of %s, %s", mode.Name, err)
+		}
+		for _, s := range stats {
+			if !cfg.Mode(mode.Name).HasRegion(s.Region) {
+				continue
+			}
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%d\t%s\t%s\n",
+				mode.Name, s.Season, s.Region, s.Points, s.Players, formatTime(s.FirstSeen), formatTime(s.LastSeen))
+		}
+	}
+	tw.Flush()
+}
+
+// formatTime formats a unix timestamp in UTC
+func formatTime(t int64) string {
+	return time.Unix(t, 0).UTC().Format("2006-01-02 15:04")
+}
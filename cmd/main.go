@@ -1,30 +1,216 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	hs "hsleaderboards"
 	"log"
 	"os"
 	"os/signal"
-	"syscall"
+	"strings"
+	"text/tabwriter"
+	"time"
 )
 
+// command is a subcommand of the cli
+// args describes its arguments in the usage line
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(l *log.Logger, g *globals, fs *flag.FlagSet, args []string)
+}
+
+var commands = []command{
+	{"run", "", "Scrape the enabled modes on their schedule until interrupted, SIGHUP reloads the config", runCommand},
+	{"once", "", "Scrape the enabled modes a single time and exit", onceCommand},
+	{"backfill", "", "Store the final leaderboard of every past season that is missing", backfillCommand},
+	{"replay", "", "Rebuild the points from the raw response archive into a fresh database", replayCommand},
+	{"migrate", "up|status", "Apply or list the schema migrations", migrateCommand},
+	{"export", "", "Write the stored points as CSV or JSON lines", exportCommand},
+	{"query", "<name>", "Show the history of a player, duplicates like name|2 included", queryCommand},
+	{"stats", "", "Summarize the stored points by mode, season and region", statsCommand},
+	{"serve", "", "Serve the stored points over a JSON HTTP API", serveCommand},
+}
+
+// globals are the flags every command accepts, they override the config
+type globals struct {
+	config   string
+	dbPath   string
+	interval time.Duration
+	modes    string
+	regions  string
+}
+
+// register adds the global flags to a flag set
+// they can be given before or after the command
+func (g *globals) register(fs *flag.FlagSet) {
+	fs.StringVar(&g.config, "config", g.config, "YAML config file, overrides CONFIG_FILE")
+	fs.StringVar(&g.dbPath, "db", g.dbPath, "SQLite database path, overrides DB_PATH, rejected with postgres")
+	fs.DurationVar(&g.interval, "interval", g.interval, "default scrape interval like 5m, overrides INTERVAL")
+	fs.StringVar(&g.modes, "modes", g.modes, "comma separated modes, the enabled modes if empty")
+	fs.StringVar(&g.regions, "regions", g.regions, "comma separated regions, the configured regions if empty")
+}
+
+// loadConfig loads the config and applies the global flags over it
+func (g *globals) loadConfig() (*hs.Config, error) {
+	if g.config != "" {
+		os.Setenv("CONFIG_FILE", g.config)
+	}
+	cfg, err := hs.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if g.dbPath != "" {
+		if cfg.DBDriver == "postgres" {
+			return nil, fmt.Errorf("-db: only applies to sqlite3, set DB_URL for postgres")
+		}
+		cfg.DBPath = g.dbPath
+	}
+	if g.interval != 0 {
+		if g.interval < time.Second {
+			return nil, fmt.Errorf("-interval: must be at least 1s, got %s", g.interval)
+		}
+		cfg.Interval = int(g.interval / time.Second)
+	}
+	if err := cfg.Select(split(g.modes), split(strings.ToUpper(g.regions))); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func main() {
-	cfg := hs.LoadConfig()
-	l := log.Default()
-	db, _ := hs.MakeDatabase(l, cfg)
-	sc := hs.MakeScraper(db, l, cfg)
-
-	sc.AddSite(hs.MakeStandard())
-	sc.AddSite(hs.MakeWild())
-	sc.AddSite(hs.MakeBattlegrounds())
-	sc.AddSite(hs.MakeMerceneries())
-	sc.AddSite(hs.MakeClassic())
-
-	done := make(chan os.Signal, 1)
-	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-
-	go sc.Start()
-	defer sc.Stop()
-	<-done
-	l.Println("Exiting...")
+	var g globals
+	g.register(flag.CommandLine)
+	once := flag.Bool("once", false, "same as the once command")
+	flag.Usage = usage
+	flag.Parse()
+
+	var name = "run"
+	if *once {
+		name = "once"
+	}
+	var args = flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		if len(args) == 0 {
+			flag.CommandLine.SetOutput(os.Stdout)
+			usage()
+			return
+		}
+		name, args = args[0], []string{"-h"}
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(log.Default(), &g, newFlagSet(&g, cmd), args)
+			return
+		}
+	}
+	fmt.Fprintf(flag.CommandLine.Output(), "Unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the commands and the global flags
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage: hsleaderboards [flags] <command> [flags] [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(out, "\nFlags of every command:")
+	flag.PrintDefaults()
+	fmt.Fprintln(out, "\nWithout a command the scraper runs. Use \"hsleaderboards help <command>\" for the flags of a command.")
+}
+
+// newFlagSet makes the flag set of a command with the global flags
+func newFlagSet(g *globals, cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	g.register(fs)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: hsleaderboards %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// open loads the config and opens the database, exiting on errors
+func open(l *log.Logger, g *globals) (*hs.Config, hs.Storage) {
+	cfg, err := g.loadConfig()
+	if err != nil {
+		l.Fatalf("Failed loading config, %s", err)
+	}
+	db, err := hs.MakeStorage(l, cfg)
+	if err != nil {
+		l.Fatalf("Failed opening database, %s", err)
+	}
+	return cfg, db
+}
+
+// requireSchema exits if the database has pending migrations
+// commands reading points never change the schema
+func requireSchema(l *log.Logger, db hs.Storage) {
+	pending, err := db.Migrate(true)
+	if err != nil {
+		l.Fatalf("Failed reading migrations, %s", err)
+	}
+	if len(pending) > 0 {
+		l.Fatalf("Database has %d pending migrations, run \"hsleaderboards migrate up\" first", len(pending))
+	}
+}
+
+// notify returns a channel receiving the given signals
+func notify(signals ...os.Signal) chan os.Signal {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	return ch
+}
+
+// selectedModes returns every game mode enabled in the config
+func selectedModes(cfg *hs.Config) []hs.Mode {
+	var modes = make([]hs.Mode, 0, len(hs.Modes))
+	for _, mode := range hs.Modes {
+		if cfg.Mode(mode.Name).IsEnabled() {
+			modes = append(modes, mode)
+		}
+	}
+	return modes
+}
+
+// selectedRegions returns the configured regions of a mode
+// a single empty region matches every region
+func selectedRegions(cfg *hs.Config, mode hs.Mode) []string {
+	if regions := cfg.Mode(mode.Name).Regions; len(regions) > 0 {
+		return regions
+	}
+	return []string{""}
+}
+
+// allSites returns every game mode enabled in the config
+func allSites(cfg *hs.Config) []hs.Site {
+	var sites = make([]hs.Site, 0, len(hs.Modes))
+	fetcher := hs.MakeHTTPFetcher(10 * time.Second)
+	for _, mode := range selectedModes(cfg) {
+		site := hs.MakeLeaderboard(mode, fetcher)
+		cfg.Mode(mode.Name).Configure(site)
+		sites = append(sites, site)
+	}
+	return sites
+}
+
+// split splits a comma separated list, ignoring empty items
+func split(list string) []string {
+	var items = make([]string, 0)
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
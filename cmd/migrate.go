@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+// migrateCommand applies or lists the schema migrations
+func migrateCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	dryRun := fs.Bool("dry-run", false, "list the pending migrations without applying them")
+	fs.Parse(args)
+	_, db := open(l, g)
+	defer db.Close()
+
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := db.Migrate(*dryRun)
+		for _, m := range applied {
+			if *dryRun {
+				fmt.Printf("Pending %04d_%s\n", m.Version, m.Name)
+			} else {
+				fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+			}
+		}
+		if err != nil {
+			l.Fatalf("Failed migrating database, %s", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Schema is up to date")
+		}
+	case "status":
+		version, err := db.SchemaVersion()
+		if err != nil {
+			l.Fatalf("Failed reading schema version, %s", err)
+		}
+		pending, err := db.Migrate(true)
+		if err != nil {
+			l.Fatalf("Failed reading migrations, %s", err)
+		}
+		fmt.Printf("Schema version: %d\n", version)
+		for _, m := range pending {
+			fmt.Printf("Pending %04d_%s\n", m.Version, m.Name)
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
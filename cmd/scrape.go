@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	hs "hsleaderboards"
+	"log"
+	"os"
+	"syscall"
+)
+
+// runCommand scrapes the enabled modes on their schedule until interrupted
+func runCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	sc := makeScraper(l, cfg, db)
+	for _, site := range allSites(cfg) {
+		sc.AddSite(site)
+	}
+
+	done := notify(os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	reload := notify(syscall.SIGHUP)
+	sc.Start()
+	defer sc.Stop()
+	for {
+		select {
+		case <-reload:
+			reloadConfig(l, g, sc)
+		case <-done:
+			l.Println("Exiting...")
+			return
+		}
+	}
+}
+
+// reloadConfig loads the config again and applies it to the running scraper
+// the global flags still override it, an invalid config is rejected as a
+// whole and the current one is kept
+func reloadConfig(l *log.Logger, g *globals, sc *hs.Scraper) {
+	l.Println("Reloading config...")
+	cfg, err := g.loadConfig()
+	if err != nil {
+		l.Printf("Failed reloading config, keeping the current one, %s", err)
+		return
+	}
+	sc.Reload(cfg, allSites(cfg))
+}
+
+// onceCommand scrapes the enabled modes a single time
+func onceCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	sc := makeScraper(l, cfg, db)
+	for _, site := range allSites(cfg) {
+		sc.AddSite(site)
+	}
+	if err := sc.Once(); err != nil {
+		l.Fatalf("Scrape failed, %s", err)
+	}
+}
+
+// backfillCommand stores the past seasons of the selected modes and regions
+func backfillCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	sc := makeScraper(l, cfg, db)
+	for _, site := range allSites(cfg) {
+		sc.AddSite(site)
+	}
+	stopOnSignal(l, sc)
+	if err := sc.Backfill(nil); err != nil {
+		l.Fatalf("Backfill failed, %s", err)
+	}
+}
+
+// replayCommand rebuilds the points of the selected modes from the archive
+// into a fresh database
+func replayCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	dir := fs.String("archive", "", "archive directory to replay, defaults to ARCHIVE_DIR")
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+
+	if *dir == "" {
+		*dir = cfg.ArchiveDir
+	}
+	if *dir == "" {
+		l.Fatalln("No archive to replay, set -archive or ARCHIVE_DIR")
+	}
+	archive, err := hs.MakeArchive(*dir)
+	if err != nil {
+		l.Fatalf("Failed opening archive, %s", err)
+	}
+	// Replaying on top of existing points would duplicate them
+	version, err := db.SchemaVersion()
+	if err != nil {
+		l.Fatalf("Failed reading schema version, %s", err)
+	}
+	if version != 0 {
+		l.Fatalf("Replay needs a fresh database, schema version is %d", version)
+	}
+
+	sc := makeScraper(l, cfg, db)
+	sc.Archive = archive
+	for _, site := range allSites(cfg) {
+		sc.AddSite(site)
+	}
+	stopOnSignal(l, sc)
+	if err := sc.Replay(); err != nil {
+		l.Fatalf("Replay failed, %s", err)
+	}
+}
+
+// makeScraper applies the pending migrations and makes a scraper
+// archiving to ARCHIVE_DIR if it is set
+func makeScraper(l *log.Logger, cfg *hs.Config, db hs.Storage) *hs.Scraper {
+	if _, err := db.Migrate(false); err != nil {
+		l.Fatalf("Failed migrating database, %s", err)
+	}
+	sc := hs.MakeScraper(db, l, cfg)
+	if cfg.ArchiveDir != "" {
+		archive, err := hs.MakeArchive(cfg.ArchiveDir)
+		if err != nil {
+			l.Fatalf("Failed opening archive, %s", err)
+		}
+		sc.Archive = archive
+	}
+	return sc
+}
+
+// stopOnSignal stops the scraper once the process is interrupted
+func stopOnSignal(l *log.Logger, sc *hs.Scraper) {
+	done := notify(os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-done
+		l.Println("Exiting...")
+		sc.Stop()
+	}()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	hs "hsleaderboards"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// api serves the stored points of the selected modes
+type api struct {
+	cfg    *hs.Config
+	db     hs.Storage
+	logger *log.Logger
+}
+
+// statsRow is the summary of a season in a region of a mode
+type statsRow struct {
+	Mode      string `json:"mode"`
+	Season    int    `json:"season"`
+	Region    string `json:"region"`
+	Points    int    `json:"points"`
+	Players   int    `json:"players"`
+	FirstSeen int64  `json:"first_seen"`
+	LastSeen  int64  `json:"last_seen"`
+}
+
+// modeRow describes a mode that can be queried
+type modeRow struct {
+	Name    string   `json:"name"`
+	Rated   bool     `json:"rated"`
+	Scored  bool     `json:"scored"`
+	Regions []string `json:"regions"`
+}
+
+// serveCommand serves the stored points over http until interrupted
+func serveCommand(l *log.Logger, g *globals, fs *flag.FlagSet, args []string) {
+	addr := fs.String("addr", ":8080", "address to listen on")
+	fs.Parse(args)
+	cfg, db := open(l, g)
+	defer db.Close()
+	requireSchema(l, db)
+
+	a := &api{cfg: cfg, db: db, logger: l}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/modes", a.modes)
+	mux.HandleFunc("/stats", a.stats)
+	mux.HandleFunc("/points", a.points)
+	srv := &http.Server{Addr: *addr, Handler: mux}
+
+	var stopped = make(chan struct{})
+	done := notify(os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-done
+		l.Println("Exiting...")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		close(stopped)
+	}()
+	l.Printf("Serving on %s", *addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		l.Fatalf("Failed serving, %s", err)
+	}
+	<-stopped
+}
+
+// modes lists the selected modes and their regions
+func (a *api) modes(w http.ResponseWriter, r *http.Request) {
+	var modes = make([]modeRow, 0)
+	for _, mode := range selectedModes(a.cfg) {
+		regions := selectedRegions(a.cfg, mode)
+		if regions[0] == "" {
+			regions = hs.Regions
+		}
+		modes = append(modes, modeRow{Name: mode.Name, Rated: mode.Rated, Scored: mode.Scored, Regions: regions})
+	}
+	a.write(w, modes)
+}
+
+// stats summarizes every season and region of a mode, or of every mode
+func (a *api) stats(w http.ResponseWriter, r *http.Request) {
+	modes, ok := a.find(w, r.URL.Query().Get("mode"), false)
+	if !ok {
+		return
+	}
+	var rows = make([]statsRow, 0)
+	for _, mode := range modes {
+		stats, err := a.db.Stats(mode.Table)
+		if err != nil {
+			a.fail(w, err)
+			return
+		}
+		for _, s := range stats {
+			if !a.cfg.Mode(mode.Name).HasRegion(s.Region) {
+				continue
+			}
+			rows = append(rows, statsRow{mode.Name, s.Season, s.Region, s.Points, s.Players, s.FirstSeen, s.LastSeen})
+		}
+	}
+	a.write(w, rows)
+}
+
+// points streams the points of a mode as a JSON array
+// filtered by the season, region and name query parameters
+func (a *api) points(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	modes, ok := a.find(w, query.Get("mode"), true)
+	if !ok {
+		return
+	}
+	var filter = hs.PointFilter{
+		Region: strings.ToUpper(query.Get("region")),
+		Name:   query.Get("name"),
+	}
+	if season := query.Get("season"); season != "" {
+		parsed, err := strconv.Atoi(season)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid season %q", season), http.StatusBadRequest)
+			return
+		}
+		filter.Season = parsed
+	}
+	served := a.cfg.Mode(modes[0].Name)
+	if filter.Region != "" && !served.HasRegion(filter.Region) {
+		http.Error(w, fmt.Sprintf("region %s is not served", filter.Region), http.StatusNotFound)
+		return
+	}
+
+	// Once the array started errors can only be logged
+	var count int
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	fmt.Fprint(w, "[")
+	err := a.db.Points(modes[0].Table, filter, func(p *hs.Point) error {
+		if filter.Region == "" && !served.HasRegion(p.Region) {
+			return nil
+		}
+		if count > 0 {
+			fmt.Fprint(w, ",")
+		}
+		count++
+		return enc.Encode(makeRow(modes[0], p))
+	})
+	fmt.Fprint(w, "]")
+	if err != nil {
+		a.logger.Printf("Failed serving points of %s, %s", modes[0].Name, err)
+	}
+}
+
+// find looks up a selected mode by name, every mode if the name is empty
+// and not required, it writes the error response if there is none
+func (a *api) find(w http.ResponseWriter, name string, required bool) ([]hs.Mode, bool) {
+	if name == "" && !required {
+		return selectedModes(a.cfg), true
+	}
+	for _, mode := range selectedModes(a.cfg) {
+		if strings.EqualFold(mode.Name, name) {
+			return []hs.Mode{mode}, true
+		}
+	}
+	if name == "" {
+		http.Error(w, "missing mode", http.StatusBadRequest)
+	} else {
+		http.Error(w, fmt.Sprintf("unknown mode %q", name), http.StatusNotFound)
+	}
+	return nil, false
+}
+
+// write writes a value as JSON
+func (a *api) write(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		a.logger.Printf("Failed writing response, %s", err)
+	}
+}
+
+// fail logs an error and responds with a server error
+func (a *api) fail(w http.ResponseWriter, err error) {
+	a.logger.Printf("Failed serving request, %s", err)
+	http.Error(w, "internal error", http.StatusInternalServerError)
+}
@@ -1,30 +1,416 @@
 package hsleaderboards
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Regions lists the regions the leaderboard api serves
+var Regions = []string{"US", "EU", "AP"}
+
+// defaultInterval is the global interval in seconds when INTERVAL is unset
+const defaultInterval = 600
+
 type Config struct {
-	Interval int
-	DBPath   string
+	Interval    int
+	DBDriver    string
+	DBPath      string
+	DBURL       string
+	Concurrency int
+	MaxFailures int
+	ArchiveDir  string
+	LogLevel    string
+	File        string
+	Modes       map[string]*ModeConfig
+}
+
+// ModeConfig holds the settings of a single game mode from the config file
+// zero values keep the defaults of the mode, retries only when unset
+type ModeConfig struct {
+	Enabled  *bool    `yaml:"enabled"`
+	Regions  []string `yaml:"regions"`
+	Interval Duration `yaml:"interval"`
+	Jitter   Duration `yaml:"jitter"`
+	Cron     string   `yaml:"cron"`
+	Retries  *int     `yaml:"retries"`
+	BaseURL  string   `yaml:"base_url"`
+}
+
+// fileConfig is the layout of the config file
+// every setting is optional and overrides the environment
+type fileConfig struct {
+	Interval    *Duration              `yaml:"interval"`
+	DBDriver    *string                `yaml:"db_driver"`
+	DBPath      *string                `yaml:"db_path"`
+	DBURL       *string                `yaml:"db_url"`
+	Concurrency *int                   `yaml:"concurrency"`
+	MaxFailures *int                   `yaml:"max_failures"`
+	ArchiveDir  *string                `yaml:"archive_dir"`
+	LogLevel    *string                `yaml:"log_level"`
+	Modes       map[string]*ModeConfig `yaml:"modes"`
+}
+
+// Duration is a duration in the config file
+// either a Go duration like 10m or a number of seconds
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	if seconds, err := strconv.Atoi(node.Value); err == nil {
+		*d = Duration(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	parsed, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+	*d = Duration(parsed)
+	return nil
 }
 
-func LoadConfig() *Config {
+// LoadConfig loads the config from the environment and .env,
+// then layers the config file from CONFIG_FILE over it
+func LoadConfig() (*Config, error) {
 	godotenv.Load()
-	var interval = 600
+	var interval = defaultInterval
+	var dbdriver = "sqlite3"
 	var dbpath = "hearthstone.db"
-	val, err := strconv.Atoi(os.Getenv("INTERVAL"))
-	if err == nil && val != 0 {
-		interval = val
+	var concurrency = 4
+	var maxFailures = 0
+	var logLevel = "info"
+	if val := os.Getenv("INTERVAL"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("INTERVAL: must be a positive number of seconds, got %q", val)
+		}
+		interval = parsed
+	}
+	if val := os.Getenv("DB_DRIVER"); val != "" {
+		dbdriver = val
 	}
 	if val := os.Getenv("DB_PATH"); val != "" {
 		dbpath = val
 	}
-	return &Config{
-		DBPath:   dbpath,
-		Interval: interval,
+	val, err := strconv.Atoi(os.Getenv("CONCURRENCY"))
+	if err == nil && val > 0 {
+		concurrency = val
+	}
+	val, err = strconv.Atoi(os.Getenv("MAX_FAILURES"))
+	if err == nil && val >= 0 {
+		maxFailures = val
+	}
+	if val := os.Getenv("LOG_LEVEL"); val != "" {
+		logLevel = val
+	}
+	var cfg = &Config{
+		DBDriver:    dbdriver,
+		DBPath:      dbpath,
+		DBURL:       os.Getenv("DB_URL"),
+		Interval:    interval,
+		Concurrency: concurrency,
+		MaxFailures: maxFailures,
+		ArchiveDir:  os.Getenv("ARCHIVE_DIR"),
+		LogLevel:    logLevel,
+		File:        os.Getenv("CONFIG_FILE"),
+		Modes:       make(map[string]*ModeConfig),
+	}
+	if cfg.File != "" {
+		if err := cfg.LoadFile(cfg.File); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// LoadFile layers a YAML config file over the config
+// unknown keys and invalid values are rejected
+func (cfg *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var file fileConfig
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := file.validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if file.Interval != nil {
+		cfg.Interval = int(time.Duration(*file.Interval) / time.Second)
+	}
+	if file.DBDriver != nil {
+		cfg.DBDriver = *file.DBDriver
+	}
+	if file.DBPath != nil {
+		cfg.DBPath = *file.DBPath
+	}
+	if file.DBURL != nil {
+		cfg.DBURL = *file.DBURL
+	}
+	if file.Concurrency != nil {
+		cfg.Concurrency = *file.Concurrency
+	}
+	if file.MaxFailures != nil {
+		cfg.MaxFailures = *file.MaxFailures
+	}
+	if file.ArchiveDir != nil {
+		cfg.ArchiveDir = *file.ArchiveDir
+	}
+	if file.LogLevel != nil {
+		cfg.LogLevel = *file.LogLevel
+	}
+	cfg.Modes = make(map[string]*ModeConfig)
+	for name, mode := range file.Modes {
+		cfg.Modes[strings.ToLower(name)] = mode
+	}
+	return nil
+}
+
+// validate checks every setting of the file
+func (f *fileConfig) validate() error {
+	if f.Interval != nil && time.Duration(*f.Interval) < time.Second {
+		return fmt.Errorf("interval: must be at least 1s, got %s", time.Duration(*f.Interval))
+	}
+	if f.DBDriver != nil {
+		switch *f.DBDriver {
+		case "sqlite3", "sqlite", "postgres":
+		default:
+			return fmt.Errorf("db_driver: unknown driver %q, expected sqlite3 or postgres", *f.DBDriver)
+		}
+	}
+	if f.Concurrency != nil && *f.Concurrency < 1 {
+		return fmt.Errorf("concurrency: must be at least 1, got %d", *f.Concurrency)
+	}
+	if f.MaxFailures != nil && *f.MaxFailures < 0 {
+		return fmt.Errorf("max_failures: must not be negative, got %d", *f.MaxFailures)
+	}
+	if f.LogLevel != nil {
+		if _, err := ParseLevel(*f.LogLevel); err != nil {
+			return fmt.Errorf("log_level: %w", err)
+		}
+	}
+	var seen = make(map[string]string)
+	for name, mode := range f.Modes {
+		if _, ok := findMode(name); !ok {
+			return fmt.Errorf("modes: unknown mode %q, expected one of %s", name, strings.Join(modeNames(), ", "))
+		}
+		if other, ok := seen[strings.ToLower(name)]; ok {
+			return fmt.Errorf("modes: %q and %q are the same mode", other, name)
+		}
+		seen[strings.ToLower(name)] = name
+		if mode == nil {
+			continue
+		}
+		if err := mode.validate(); err != nil {
+			return fmt.Errorf("modes.%s.%w", name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks the settings of a single mode
+func (m *ModeConfig) validate() error {
+	if m.Regions != nil && len(m.Regions) == 0 {
+		return fmt.Errorf("regions: must not be empty, disable the mode instead")
+	}
+	for _, region := range m.Regions {
+		if !contains(Regions, region) {
+			return fmt.Errorf("regions: unknown region %q, expected one of %s", region, strings.Join(Regions, ", "))
+		}
+	}
+	if m.Interval < 0 || (m.Interval > 0 && time.Duration(m.Interval) < time.Second) {
+		return fmt.Errorf("interval: must be at least 1s, got %s", time.Duration(m.Interval))
+	}
+	if m.Jitter < 0 {
+		return fmt.Errorf("jitter: must not be negative, got %s", time.Duration(m.Jitter))
+	}
+	if m.Cron != "" {
+		if _, err := parseCron(m.Cron); err != nil {
+			return fmt.Errorf("cron: %w", err)
+		}
+	}
+	if m.Retries != nil && *m.Retries < 0 {
+		return fmt.Errorf("retries: must not be negative, got %d", *m.Retries)
+	}
+	if m.BaseURL != "" {
+		u, err := url.Parse(m.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("base_url: expected an http or https url like %s, got %q", DefaultBaseURL, m.BaseURL)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+			return fmt.Errorf("base_url: must not have a path or query, got %q", m.BaseURL)
+		}
+	}
+	return nil
+}
+
+// KeepStartupSettings reverts the settings of next that only apply on
+// startup to their current value and describes every reverted change
+func (cfg *Config) KeepStartupSettings(next *Config) []string {
+	var changes = make([]string, 0)
+	keep := func(name string, curr string, val *string) {
+		if *val != curr {
+			changes = append(changes, fmt.Sprintf("%s %q -> %q", name, curr, *val))
+			*val = curr
+		}
+	}
+	keep("db_driver", cfg.DBDriver, &next.DBDriver)
+	keep("db_path", cfg.DBPath, &next.DBPath)
+	keep("archive_dir", cfg.ArchiveDir, &next.ArchiveDir)
+	// The url may hold a password
+	if next.DBURL != cfg.DBURL {
+		changes = append(changes, "db_url")
+		next.DBURL = cfg.DBURL
+	}
+	if next.Concurrency != cfg.Concurrency {
+		changes = append(changes, fmt.Sprintf("concurrency %d -> %d", cfg.Concurrency, next.Concurrency))
+		next.Concurrency = cfg.Concurrency
+	}
+	return changes
+}
+
+// Select scrapes only the given modes in the given regions
+// empty lists keep the modes and regions of the config
+func (cfg *Config) Select(modes, regions []string) error {
+	for _, name := range modes {
+		if _, ok := findMode(name); !ok {
+			return fmt.Errorf("unknown mode %q, expected one of %s", name, strings.Join(modeNames(), ", "))
+		}
+	}
+	for _, region := range regions {
+		if !contains(Regions, region) {
+			return fmt.Errorf("unknown region %q, expected one of %s", region, strings.Join(Regions, ", "))
+		}
+	}
+	if cfg.Modes == nil {
+		cfg.Modes = make(map[string]*ModeConfig)
+	}
+	for _, mode := range Modes {
+		var key = strings.ToLower(mode.Name)
+		var settings = cfg.Mode(mode.Name)
+		if len(modes) > 0 {
+			var enabled = false
+			for _, name := range modes {
+				enabled = enabled || strings.EqualFold(name, mode.Name)
+			}
+			settings.Enabled = &enabled
+		}
+		if len(regions) > 0 {
+			settings.Regions = append([]string{}, regions...)
+		}
+		cfg.Modes[key] = settings
+	}
+	return nil
+}
+
+// Mode returns the settings of a mode from the config file
+// modes without settings get an empty one
+func (cfg *Config) Mode(name string) *ModeConfig {
+	if mode := cfg.Modes[strings.ToLower(name)]; mode != nil {
+		return mode
+	}
+	return &ModeConfig{}
+}
+
+// IsEnabled checks if a mode should be scraped, modes are enabled by default
+func (m *ModeConfig) IsEnabled() bool {
+	return m.Enabled == nil || *m.Enabled
+}
+
+// HasRegion checks if a mode covers a region, every region if none are set
+func (m *ModeConfig) HasRegion(region string) bool {
+	return len(m.Regions) == 0 || contains(m.Regions, region)
+}
+
+// Configure applies the settings of the config file to a leaderboard
+// retries come after the first try, 0 disables them
+func (m *ModeConfig) Configure(b *Leaderboard) {
+	if len(m.Regions) > 0 {
+		b.Regions = append([]string{}, m.Regions...)
+	}
+	if m.Retries != nil {
+		b.Retry.Attempts = *m.Retries + 1
+	}
+	if m.BaseURL != "" {
+		b.URL = strings.TrimSuffix(m.BaseURL, "/") + b.Mode.Path
+	}
+}
+
+// LoadSchedule loads the schedule of a site from the config file
+// and the environment, the file takes precedence
+// <NAME>_INTERVAL and <NAME>_JITTER are in seconds and <NAME>_CRON
+// is a cron expression, the interval defaults to the global one
+func (cfg *Config) LoadSchedule(name string) (*Schedule, error) {
+	var prefix = strings.ToUpper(name)
+	var mode = cfg.Mode(name)
+	var interval = time.Duration(cfg.Interval) * time.Second
+	var jitter time.Duration
+	var cron = os.Getenv(prefix + "_CRON")
+	if val := os.Getenv(prefix + "_INTERVAL"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s_INTERVAL: %w", prefix, err)
+		}
+		interval = time.Duration(parsed) * time.Second
+	}
+	if val := os.Getenv(prefix + "_JITTER"); val != "" {
+		parsed, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s_JITTER: %w", prefix, err)
+		}
+		jitter = time.Duration(parsed) * time.Second
+	}
+	if mode.Interval > 0 {
+		interval = time.Duration(mode.Interval)
+	}
+	if mode.Jitter > 0 {
+		jitter = time.Duration(mode.Jitter)
+	}
+	if mode.Cron != "" {
+		cron = mode.Cron
+	}
+	return MakeSchedule(interval, jitter, cron)
+}
+
+// findMode looks up a mode by name, ignoring case
+func findMode(name string) (Mode, bool) {
+	for _, mode := range Modes {
+		if strings.EqualFold(mode.Name, name) {
+			return mode, true
+		}
+	}
+	return Mode{}, false
+}
+
+// modeNames lists the names of every mode, sorted
+func modeNames() []string {
+	var names = make([]string, 0, len(Modes))
+	for _, mode := range Modes {
+		names = append(names, mode.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contains checks if a list holds a value
+func contains(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
 	}
+	return false
 }
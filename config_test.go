@@ -0,0 +1,145 @@
+package hsleaderboards
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfig writes a config file into a temporary directory
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileOverridesEnvironment(t *testing.T) {
+	cfg := &Config{Interval: 600, DBDriver: "sqlite3", DBPath: "hearthstone.db", Concurrency: 4}
+	path := writeConfig(t, `
+interval: 5m
+db_path: other.db
+modes:
+  standard:
+    regions: [EU]
+    interval: 90
+    retries: 5
+    base_url: http://localhost:8080/
+  Classic:
+    enabled: false
+  Wild:
+    retries: 0
+`)
+	if err := cfg.LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Interval != 300 || cfg.DBPath != "other.db" || cfg.Concurrency != 4 {
+		t.Fatalf("got interval %d, db path %s, concurrency %d", cfg.Interval, cfg.DBPath, cfg.Concurrency)
+	}
+	if cfg.Mode("Classic").IsEnabled() || !cfg.Mode("Wild").IsEnabled() {
+		t.Fatal("only Classic should be disabled")
+	}
+	site := MakeLeaderboard(Standard, nil)
+	cfg.Mode("Standard").Configure(site)
+	if len(site.Regions) != 1 || site.Regions[0] != "EU" {
+		t.Fatalf("regions = %v, want [EU]", site.Regions)
+	}
+	if site.Retry.Attempts != 6 {
+		t.Fatalf("attempts = %d, want 6", site.Retry.Attempts)
+	}
+	wild := MakeLeaderboard(Wild, nil)
+	cfg.Mode("Wild").Configure(wild)
+	if wild.Retry.Attempts != 1 {
+		t.Fatalf("wild attempts = %d, want 1", wild.Retry.Attempts)
+	}
+	classic := MakeLeaderboard(Classic, nil)
+	cfg.Mode("Classic").Configure(classic)
+	if classic.Retry.Attempts != DefaultRetry.Attempts {
+		t.Fatalf("classic attempts = %d, want %d", classic.Retry.Attempts, DefaultRetry.Attempts)
+	}
+	if site.URL != "http://localhost:8080"+Standard.Path {
+		t.Fatalf("url = %s", site.URL)
+	}
+	schedule, err := cfg.LoadSchedule("Standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schedule.Interval != 90*time.Second {
+		t.Fatalf("interval = %s, want 1m30s", schedule.Interval)
+	}
+}
+
+func TestLoadFileRejectsInvalidSettings(t *testing.T) {
+	cases := map[string]string{
+		"field interval_seconds not found": "interval_seconds: 5",
+		"unknown mode \"Ranked\"":          "modes:\n  Ranked: {}",
+		"unknown region \"CN\"":            "modes:\n  Wild:\n    regions: [US, CN]",
+		"regions: must not be empty":       "modes:\n  Wild:\n    regions: []",
+		"invalid duration \"soon\"":        "modes:\n  Wild:\n    interval: soon",
+		"interval: must be at least 1s":    "interval: 10ms",
+		"cron":                             "modes:\n  Wild:\n    cron: \"* * *\"",
+		"retries: must not be negative":    "modes:\n  Wild:\n    retries: -1",
+		"base_url: expected an http":       "modes:\n  Wild:\n    base_url: playhearthstone.com",
+		"base_url: must not have a path":   "modes:\n  Wild:\n    base_url: https://playhearthstone.com/en-us",
+		"unknown driver \"mysql\"":         "db_driver: mysql",
+		"are the same mode":                "modes:\n  wild: {}\n  Wild: {}",
+		"unknown log level \"loud\"":       "log_level: loud",
+	}
+	for want, content := range cases {
+		cfg := &Config{}
+		err := cfg.LoadFile(writeConfig(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: got error %v, want %q", content, err, want)
+		}
+	}
+}
+
+func TestLoadFileAcceptsExample(t *testing.T) {
+	if err := (&Config{}).LoadFile("config.example.yaml"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSelectOverridesModesAndRegions(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.LoadFile(writeConfig(t, "modes:\n  Wild:\n    enabled: false\n    interval: 90")); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Select([]string{"wild", "Arena"}, []string{"EU"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range Modes {
+		enabled := mode.Name == "Wild" || mode.Name == "Arena"
+		if cfg.Mode(mode.Name).IsEnabled() != enabled {
+			t.Errorf("%s enabled = %t, want %t", mode.Name, !enabled, enabled)
+		}
+	}
+	if wild := cfg.Mode("Wild"); len(wild.Regions) != 1 || wild.Regions[0] != "EU" || wild.Interval != Duration(90*time.Second) {
+		t.Fatalf("wild = %+v, want regions [EU] and its interval kept", wild)
+	}
+	if err := cfg.Select([]string{"Ranked"}, nil); err == nil || !strings.Contains(err.Error(), "unknown mode \"Ranked\"") {
+		t.Fatalf("got error %v, want unknown mode", err)
+	}
+	if err := cfg.Select(nil, []string{"CN"}); err == nil || !strings.Contains(err.Error(), "unknown region \"CN\"") {
+		t.Fatalf("got error %v, want unknown region", err)
+	}
+}
+
+func TestLoadConfigRejectsInvalidInterval(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	for _, val := range []string{"-1", "0", "soon"} {
+		t.Setenv("INTERVAL", val)
+		if _, err := LoadConfig(); err == nil || !strings.Contains(err.Error(), "INTERVAL: must be a positive number") {
+			t.Errorf("INTERVAL=%s: got error %v, want a positive number", val, err)
+		}
+	}
+	t.Setenv("INTERVAL", "90")
+	cfg, err := LoadConfig()
+	if err != nil || cfg.Interval != 90 {
+		t.Fatalf("got interval %v, %v, want 90", cfg, err)
+	}
+}
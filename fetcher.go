@@ -0,0 +1,44 @@
+package hsleaderboards
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Fetcher gets the raw body of a leaderboard page
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) ([]byte, error)
+}
+
+// HTTPFetcher fetches pages with a plain http client
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+func MakeHTTPFetcher(timeout time.Duration) *HTTPFetcher {
+	return &HTTPFetcher{
+		Client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Fetch returns a StatusError for responses without a 2xx status
+func (f *HTTPFetcher) Fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode < 200 || r.StatusCode > 299 {
+		return nil, &StatusError{
+			Code:       r.StatusCode,
+			RetryAfter: parseRetryAfter(r.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	return ioutil.ReadAll(r.Body)
+}
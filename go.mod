@@ -4,8 +4,10 @@ go 1.18
 
 require (
 	github.com/joho/godotenv v1.4.0
+	github.com/lib/pq v1.9.0
 	github.com/mattn/go-sqlite3 v1.14.13
 	github.com/tidwall/gjson v1.14.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
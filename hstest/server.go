@@ -0,0 +1,193 @@
+// Package hstest provides a fake leaderboard server for tests
+package hstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	hs "hsleaderboards"
+)
+
+// Row is a single leaderboard entry served by the fake
+// Rating is left out of the response when zero
+type Row struct {
+	Name   string `json:"accountid"`
+	Rank   int    `json:"rank"`
+	Rating int    `json:"rating,omitempty"`
+}
+
+// failure is a scripted error response
+type failure struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+// Server is a fake of the leaderboard api
+// every leaderboard serves the rows set for its region and season,
+// split into pages of PageSize rows
+type Server struct {
+	*httptest.Server
+	PageSize int
+	mu       sync.Mutex
+	seasons  map[string][]int
+	rows     map[string][]Row
+	failures map[string][]failure
+	requests int
+}
+
+func NewServer() *Server {
+	var s = &Server{
+		PageSize: 2,
+		seasons:  make(map[string][]int),
+		rows:     make(map[string][]Row),
+		failures: make(map[string][]failure),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Seasons sets the seasons listed in the metadata of a leaderboard
+func (s *Server) Seasons(id string, seasons ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seasons[id] = seasons
+}
+
+// RegionSeasons sets the seasons listed in the metadata of a single region
+// it takes precedence over Seasons
+func (s *Server) RegionSeasons(id, region string, seasons ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seasons[id+"|"+region] = seasons
+}
+
+// Set sets the rows of a leaderboard, replacing the last snapshot
+func (s *Server) Set(id, region string, season int, rows ...Row) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows[key(id, region, season)] = rows
+}
+
+// Fail makes the next count requests of a leaderboard fail
+// with the given status and body
+func (s *Server) Fail(id string, count, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures[id] = append(s.failures[id], failure{status: status, body: body})
+	}
+}
+
+// Throttle makes the next count requests of a leaderboard
+// fail with 429 and the given Retry-After header
+func (s *Server) Throttle(id string, count int, retryAfter string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures[id] = append(s.failures[id], failure{status: http.StatusTooManyRequests, retryAfter: retryAfter})
+	}
+}
+
+// Requests returns how many requests the server got
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Fetcher returns a fetcher that sends every request to the server
+// so sites can keep their real urls
+func (s *Server) Fetcher() hs.Fetcher {
+	base, _ := url.Parse(s.URL)
+	client := s.Client()
+	client.Transport = &rewrite{base: base, next: client.Transport}
+	return &hs.HTTPFetcher{Client: client}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	var query = r.URL.Query()
+	var id = query.Get("leaderboardId")
+	var region = query.Get("region")
+	season, _ := strconv.Atoi(query.Get("seasonId"))
+	page, _ := strconv.Atoi(query.Get("page"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests++
+	if failures := s.failures[id]; len(failures) > 0 {
+		s.failures[id] = failures[1:]
+		if failures[0].retryAfter != "" {
+			w.Header().Set("Retry-After", failures[0].retryAfter)
+		}
+		w.WriteHeader(failures[0].status)
+		fmt.Fprint(w, failures[0].body)
+		return
+	}
+	var rows = s.rows[key(id, region, season)]
+	var pages = (len(rows) + s.PageSize - 1) / s.PageSize
+	var start, end = (page - 1) * s.PageSize, page * s.PageSize
+	if start < 0 || start > len(rows) {
+		start = len(rows)
+	}
+	if end > len(rows) {
+		end = len(rows)
+	}
+	if end < start {
+		end = start
+	}
+	var listed, ok = s.seasons[id+"|"+region]
+	if !ok {
+		listed = s.seasons[id]
+	}
+	var seasons = make(map[string]string)
+	for _, val := range listed {
+		seasons[strconv.Itoa(val)] = SeasonStart(val).Format("2006-01-02T15:04:05.000Z")
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"seasonId": season,
+		"region":   region,
+		"leaderboard": map[string]interface{}{
+			"leaderboard_id": id,
+			"rows":           append([]Row{}, rows[start:end]...),
+			"pagination": map[string]interface{}{
+				"totalPages": pages,
+				"totalSize":  len(rows),
+			},
+		},
+		"metaData": map[string]interface{}{
+			id: map[string]interface{}{
+				"seasonsWithStartDate": seasons,
+			},
+		},
+	})
+}
+
+// SeasonStart returns the start date the fake lists for a season
+func SeasonStart(season int) time.Time {
+	return time.Date(2020, 1, 1+season, 0, 0, 0, 0, time.UTC)
+}
+
+func key(id, region string, season int) string {
+	return fmt.Sprintf("%s|%s|%d", id, region, season)
+}
+
+// rewrite sends every request to the fake server
+type rewrite struct {
+	base *url.URL
+	next http.RoundTripper
+}
+
+func (t *rewrite) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.base.Scheme
+	req.URL.Host = t.base.Host
+	req.Host = t.base.Host
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,173 @@
+package hsleaderboards
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// identity is a leaderboard row of an account name shared by several players
+// Label is the name the row is stored under, like name or name|2
+type identity struct {
+	Label  string
+	Rank   int
+	Rating int
+	Score  float64
+}
+
+// scoreScale weighs score differences against ranks,
+// a hundredth of a score counts as much as a rank
+const scoreScale = 100
+
+// identityPair is a possible match between a new and a previous row
+type identityPair struct {
+	next, prev int
+	cost       int
+}
+
+// baseName strips the duplicate suffix from a label
+func baseName(label string) string {
+	if idx := strings.LastIndex(label, "|"); idx != -1 {
+		if _, err := strconv.Atoi(label[idx+1:]); err == nil {
+			return label[:idx]
+		}
+	}
+	return label
+}
+
+// duplicateLabel returns the label of the nth player with a name
+func duplicateLabel(base string, n int) string {
+	if n == 1 {
+		return base
+	}
+	return fmt.Sprintf("%s|%d", base, n)
+}
+
+// matchIdentities assigns the rows sharing an account name to the labels
+// they had in the previous snapshot, keeping each player attached to its
+// history by rank and rating continuity
+// new rows get labels that are neither in the previous snapshot nor stored,
+// so they never take over the history of a player that left
+// returns the label and match confidence of every new row, confidence is
+// 1 when there was nothing to confuse the row with and 0.5 when the
+// competing rows were equally close
+func matchIdentities(base string, prev, next []identity, stored []string) (labels []string, confidence []float64) {
+	labels = make([]string, len(next))
+	confidence = make([]float64, len(next))
+	// Nothing to confuse, the rows keep their labels
+	if len(prev) == 0 || (len(prev) == 1 && len(next) == 1) {
+		for i := range next {
+			labels[i] = next[i].Label
+			confidence[i] = 1
+		}
+		if len(prev) == 1 {
+			labels[0] = prev[0].Label
+		}
+		return
+	}
+	var costs = make([][]int, len(next))
+	var pairs = make([]identityPair, 0, len(prev)*len(next))
+	for i := range next {
+		costs[i] = make([]int, len(prev))
+		for j := range prev {
+			costs[i][j] = identityCost(&next[i], &prev[j])
+			pairs = append(pairs, identityPair{next: i, prev: j, cost: costs[i][j]})
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a].cost != pairs[b].cost {
+			return pairs[a].cost < pairs[b].cost
+		}
+		if pairs[a].next != pairs[b].next {
+			return pairs[a].next < pairs[b].next
+		}
+		return pairs[a].prev < pairs[b].prev
+	})
+	// Closest rows are matched first
+	var matches = make([]identityPair, 0, len(next))
+	var matched = make([]bool, len(next))
+	var taken = make([]bool, len(prev))
+	for _, pair := range pairs {
+		if matched[pair.next] || taken[pair.prev] {
+			continue
+		}
+		matched[pair.next] = true
+		taken[pair.prev] = true
+		matches = append(matches, pair)
+	}
+	for _, match := range matches {
+		labels[match.next] = prev[match.prev].Label
+		confidence[match.next] = matchConfidence(match, matches, costs, matched, taken)
+	}
+	// Labels are never reused, they belong to players that left
+	var used = make(map[string]bool)
+	for _, id := range prev {
+		used[id.Label] = true
+	}
+	for _, label := range stored {
+		used[label] = true
+	}
+	n := 1
+	for i := range next {
+		if matched[i] {
+			continue
+		}
+		for used[duplicateLabel(base, n)] {
+			n++
+		}
+		labels[i] = duplicateLabel(base, n)
+		used[labels[i]] = true
+		confidence[i] = 1
+	}
+	return
+}
+
+// needsStored checks if matching would label new rows,
+// which is when there are more rows than previous labels to match
+func needsStored(prev, next []identity) bool {
+	return len(prev) > 0 && len(next) > len(prev)
+}
+
+// identityCost is how far apart two rows are
+func identityCost(a, b *identity) int {
+	score := int(math.Round(math.Abs(a.Score-b.Score) * scoreScale))
+	return abs(a.Rank-b.Rank) + abs(a.Rating-b.Rating) + score
+}
+
+// matchConfidence compares a match to every alternative that swaps
+// one of its rows, either with another match or with an unmatched row
+func matchConfidence(match identityPair, matches []identityPair, costs [][]int, matched, taken []bool) float64 {
+	var result = 1.0
+	var compare = func(own, swap int) {
+		// Smoothed so that close rows are never fully certain
+		if val := float64(swap+1) / float64(own+swap+2); val < result {
+			result = val
+		}
+	}
+	for _, other := range matches {
+		if other == match {
+			continue
+		}
+		compare(match.cost+other.cost, costs[match.next][other.prev]+costs[other.next][match.prev])
+	}
+	for j := range taken {
+		if !taken[j] {
+			compare(match.cost, costs[match.next][j])
+		}
+	}
+	for i := range matched {
+		if !matched[i] {
+			compare(match.cost, costs[i][match.prev])
+		}
+	}
+	return result
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,80 @@
+package hsleaderboards
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMatchIdentities(t *testing.T) {
+	cases := []struct {
+		name       string
+		prev, next []identity
+		stored     []string
+		labels     []string
+		confidence []float64
+	}{
+		{
+			name:       "swap",
+			prev:       []identity{{"a", 1, 9000, 0}, {"a|2", 2, 8000, 0}},
+			next:       []identity{{"a", 1, 8010, 0}, {"a|2", 2, 8990, 0}},
+			labels:     []string{"a|2", "a"},
+			confidence: []float64{1981.0 / 2004, 1981.0 / 2004},
+		},
+		{
+			name:       "newcomer",
+			prev:       []identity{{"a", 1, 9000, 0}, {"a|2", 5, 7000, 0}},
+			next:       []identity{{"a", 1, 9000, 0}, {"a|2", 3, 8000, 0}, {"a|3", 5, 7000, 0}},
+			labels:     []string{"a", "a|3", "a|2"},
+			confidence: []float64{1003.0 / 1004, 1, 1003.0 / 1004},
+		},
+		{
+			name:       "newcomer skips stored labels",
+			prev:       []identity{{"a", 1, 9000, 0}, {"a|2", 5, 7000, 0}},
+			next:       []identity{{"a", 1, 9000, 0}, {"a|2", 3, 8000, 0}, {"a|3", 5, 7000, 0}},
+			stored:     []string{"a", "a|2", "a|3"},
+			labels:     []string{"a", "a|4", "a|2"},
+			confidence: []float64{1003.0 / 1004, 1, 1003.0 / 1004},
+		},
+		{
+			name:       "leaver",
+			prev:       []identity{{"a", 1, 9000, 0}, {"a|2", 5, 7000, 0}},
+			next:       []identity{{"a", 5, 7000, 0}},
+			labels:     []string{"a|2"},
+			confidence: []float64{2005.0 / 2006},
+		},
+		{
+			name:       "tie",
+			prev:       []identity{{"a", 1, 0, 0}, {"a|2", 3, 0, 0}},
+			next:       []identity{{"a", 2, 0, 0}},
+			labels:     []string{"a"},
+			confidence: []float64{0.5},
+		},
+		{
+			name:       "arena swap",
+			prev:       []identity{{"a", 1, 0, 8.9}, {"a|2", 2, 0, 8.4}},
+			next:       []identity{{"a", 1, 0, 8.41}, {"a|2", 2, 0, 8.88}},
+			labels:     []string{"a|2", "a"},
+			confidence: []float64{98.0 / 104, 98.0 / 104},
+		},
+		{
+			name:       "single row keeps its label",
+			prev:       []identity{{"a|2", 4, 0, 0}},
+			next:       []identity{{"a", 9, 0, 0}},
+			labels:     []string{"a|2"},
+			confidence: []float64{1},
+		},
+	}
+	for _, c := range cases {
+		labels, confidence := matchIdentities("a", c.prev, c.next, c.stored)
+		if !reflect.DeepEqual(labels, c.labels) {
+			t.Errorf("%s: labels = %v, want %v", c.name, labels, c.labels)
+		}
+		for i := range confidence {
+			if math.Abs(confidence[i]-c.confidence[i]) > 1e-9 {
+				t.Errorf("%s: confidence = %v, want %v", c.name, confidence, c.confidence)
+				break
+			}
+		}
+	}
+}
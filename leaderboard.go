@@ -0,0 +1,554 @@
+package hsleaderboards
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Leaderboard scrapes a single leaderboard of the api
+// every game mode is a Leaderboard configured by its Mode
+type Leaderboard struct {
+	Mode          Mode
+	URL           string
+	Regions       []string
+	Retry         RetryPolicy
+	Fetcher       Fetcher
+	Db            Storage
+	Sc            *Scraper
+	CurrSnapshots map[string]*Response
+	PrevSnapshots map[string]*Response
+	LatestSeason  int
+	NextSeason    int
+	Logger        *Logger
+	mu            sync.Mutex
+}
+
+func (b *Leaderboard) Name() string {
+	return b.Mode.Name
+}
+
+func (b *Leaderboard) Initialize(ctx context.Context, sc *Scraper, db Storage) error {
+	b.mu.Lock()
+	b.Sc = sc
+	b.Db = db
+	b.Logger = sc.Logger
+	b.mu.Unlock()
+	// Getting latest season
+	res, err := b.getPage(ctx, b.regions()[0], b.LatestSeason, 1, time.Time{})
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.LatestSeason = res.Meta.Latest
+	b.Logger.Printf("[%s] Season: %d", b.Name(), b.LatestSeason)
+	// Rebuilding snapshots for comparison
+	for _, region := range b.Regions {
+		err = b.loadSnapshots(region, b.LatestSeason)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scrape gets data from all regions and saves to database
+// rolls over to the next season once it shows up
+func (b *Leaderboard) Scrape(ctx context.Context) error {
+	err := b.scrapeRegions(ctx)
+	// Every region needs a final snapshot before the season ends
+	if b.NextSeason == 0 || err != nil || ctx.Err() != nil {
+		return err
+	}
+	if err := b.rollover(time.Now()); err != nil {
+		return err
+	}
+	return b.scrapeRegions(ctx)
+}
+
+// scrapeRegions gets data from all regions in parallel
+func (b *Leaderboard) scrapeRegions(ctx context.Context) error {
+	var wg sync.WaitGroup
+	var regions = b.regions()
+	var errs = make([]error, len(regions))
+	now := time.Now()
+	for i, region := range regions {
+		wg.Add(1)
+		go func(i int, region string) {
+			defer wg.Done()
+			errs[i] = b.scrapeRegion(ctx, region, now)
+		}(i, region)
+	}
+	wg.Wait()
+	return joinErrors(errs)
+}
+
+// scrapeRegion gets data from a single region and saves to database
+func (b *Leaderboard) scrapeRegion(ctx context.Context, region string, now time.Time) error {
+	start := time.Now()
+	b.mu.Lock()
+	season := b.LatestSeason
+	b.mu.Unlock()
+	res, err := b.getResponse(ctx, region, season, now)
+	if err != nil {
+		b.Logger.Errorf("[%s] Failed to get region %s, %s", b.Name(), region, err)
+		return fmt.Errorf("region %s: %w", region, err)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	// Regions lagging behind never roll the season back
+	if res.Meta.Latest > b.LatestSeason && res.Meta.Latest > b.NextSeason {
+		b.Logger.Printf("[%s] Season changed! %d -> %d", b.Name(), b.LatestSeason, res.Meta.Latest)
+		b.NextSeason = res.Meta.Latest
+	}
+	res.Timestamp = now.Unix()
+	new, old, err := b.saveDifferences(res)
+	if err != nil {
+		b.Logger.Errorf("[%s] Failed to save region %s, %s", b.Name(), region, err)
+		return fmt.Errorf("region %s: %w", region, err)
+	}
+	if b.CurrSnapshots[region] == nil {
+		b.CurrSnapshots[region] = res
+	}
+	b.PrevSnapshots[region] = b.CurrSnapshots[region]
+	b.CurrSnapshots[region] = res
+	b.Logger.Printf("[%s] Saved region %s. New: %d, Old: %d | Took %s", b.Name(), region, new, old, time.Since(start))
+	return nil
+}
+
+func MakeLeaderboard(mode Mode, fetcher Fetcher) *Leaderboard {
+	return &Leaderboard{
+		Mode:          mode,
+		URL:           DefaultBaseURL + mode.Path,
+		Regions:       []string{"US", "EU", "AP"},
+		Retry:         DefaultRetry,
+		Fetcher:       fetcher,
+		CurrSnapshots: make(map[string]*Response),
+		PrevSnapshots: make(map[string]*Response),
+		LatestSeason:  mode.Season,
+	}
+}
+
+// Reconfigure takes over the url, retries and regions of a freshly made
+// leaderboard of the same mode, added regions continue their stored points
+func (b *Leaderboard) Reconfigure(from Site) error {
+	other, ok := from.(*Leaderboard)
+	if !ok || other.Mode.Name != b.Mode.Name {
+		return fmt.Errorf("cannot reconfigure %s from %s", b.Name(), from.Name())
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.URL = other.URL
+	b.Retry = other.Retry
+	// Sites that are not initialized yet load every region on startup
+	for _, region := range other.Regions {
+		if b.Db == nil || contains(b.Regions, region) {
+			continue
+		}
+		if err := b.loadSnapshots(region, b.LatestSeason); err != nil {
+			return fmt.Errorf("region %s: %w", region, err)
+		}
+	}
+	for _, region := range b.Regions {
+		if !contains(other.Regions, region) {
+			delete(b.CurrSnapshots, region)
+			delete(b.PrevSnapshots, region)
+		}
+	}
+	if b.Logger != nil {
+		b.Logger.Printf("[%s] Regions: %s", b.Name(), strings.Join(other.Regions, ", "))
+	}
+	b.Regions = append([]string{}, other.Regions...)
+	return nil
+}
+
+// regions returns the regions currently scraped
+func (b *Leaderboard) regions() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string{}, b.Regions...)
+}
+
+// rollover ends the current season and starts the next one in all regions
+// the last scrape is kept as the final snapshot of the ending season
+func (b *Leaderboard) rollover(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.Db.SeasonChange(b.Name(), b.LatestSeason, b.NextSeason, now.Unix())
+	if err != nil {
+		return err
+	}
+	b.Logger.Printf("[%s] Season %d ended, starting season %d", b.Name(), b.LatestSeason, b.NextSeason)
+	b.LatestSeason = b.NextSeason
+	b.NextSeason = 0
+	b.CurrSnapshots = make(map[string]*Response)
+	b.PrevSnapshots = make(map[string]*Response)
+	return nil
+}
+
+// Backfill stores the final leaderboard of every past season
+// seasons already in the database are skipped, so an interrupted
+// backfill resumes where it stopped
+func (b *Leaderboard) Backfill(ctx context.Context, regions []string) error {
+	if len(regions) == 0 {
+		regions = b.regions()
+	}
+	first, err := b.getPage(ctx, b.regions()[0], b.LatestSeason, 1, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, season := range first.Meta.Seasons {
+		// The latest season is still running
+		if season >= b.LatestSeason {
+			continue
+		}
+		for _, region := range regions {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			exists, err := b.Db.HasSeason(b.Mode.Table, season, region)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+			// The final leaderboard is dated when the season ended
+			start := time.Now()
+			end, ok := first.Meta.End(season)
+			if !ok {
+				b.Logger.Printf("[%s] No end date for season %d, dating it now", b.Name(), season)
+				end = start
+			}
+			res, err := b.getResponse(ctx, region, season, end)
+			if err != nil {
+				return fmt.Errorf("season %d region %s: %w", season, region, err)
+			}
+			res.Timestamp = end.Unix()
+			res.Season = season
+			res.Region = region
+			if err = b.saveSeason(res); err != nil {
+				return fmt.Errorf("season %d region %s: %w", season, region, err)
+			}
+			b.Logger.Printf("[%s] Backfilled season %d region %s. Rows: %d | Took %s", b.Name(), season, region, len(res.Data.Rows), time.Since(start))
+		}
+	}
+	return nil
+}
+
+// saveSeason stores a whole leaderboard as a single snapshot
+func (b *Leaderboard) saveSeason(res *Response) error {
+	var snapshot = &Snapshot{Timestamp: res.Timestamp, Season: res.Season, Region: res.Region}
+	for _, row := range res.Data.Rows {
+		snapshot.Points = append(snapshot.Points, b.toPoint(&row, res.Timestamp, res.Season, res.Region))
+	}
+	return b.Db.InsertSnapshot(b.Mode.Table, snapshot)
+}
+
+// Replay rebuilds the points of every archived scrape in order
+// scrapes with missing or broken pages are skipped
+func (b *Leaderboard) Replay(ctx context.Context, sc *Scraper, db Storage) error {
+	b.Sc = sc
+	b.Db = db
+	b.Logger = sc.Logger
+	scrapes, err := sc.Archive.Scrapes(b.Name())
+	if err != nil {
+		return err
+	}
+	// Backfilled seasons are mixed with live scrapes,
+	// so every season of a region keeps its own snapshots
+	var curr = make(map[string]*Response)
+	var prev = make(map[string]*Response)
+	for _, scrape := range scrapes {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		res, err := b.loadScrape(sc.Archive, &scrape)
+		if err != nil {
+			b.Logger.Printf("[%s] Skipped region %s at %d, %s", b.Name(), scrape.Region, scrape.Timestamp, err)
+			continue
+		}
+		key := fmt.Sprintf("%s|%d", res.Region, res.Season)
+		b.CurrSnapshots[res.Region] = curr[key]
+		b.PrevSnapshots[res.Region] = prev[key]
+		new, old, err := b.saveDifferences(res)
+		if err != nil {
+			return fmt.Errorf("region %s at %d: %w", res.Region, res.Timestamp, err)
+		}
+		if curr[key] == nil {
+			curr[key] = res
+		}
+		prev[key] = curr[key]
+		curr[key] = res
+		b.Logger.Printf("[%s] Replayed season %d region %s at %d. New: %d, Old: %d", b.Name(), res.Season, res.Region, res.Timestamp, new, old)
+	}
+	return nil
+}
+
+// loadScrape rebuilds a response from the archived pages of a scrape
+func (b *Leaderboard) loadScrape(archive *Archive, scrape *ArchivedScrape) (*Response, error) {
+	var response *Response
+	for _, page := range scrape.Pages {
+		body, err := archive.Load(&page)
+		if err != nil {
+			return nil, err
+		}
+		var res = b.newResponse()
+		if err = json.Unmarshal(body, res); err != nil {
+			return nil, fmt.Errorf("page %d: %w", page.Page, err)
+		}
+		if response == nil {
+			response = res
+			continue
+		}
+		response.Data.Merge(&res.Data)
+	}
+	if response == nil || !scrape.Complete(response.Data.Pages) {
+		return nil, fmt.Errorf("missing pages")
+	}
+	response.Timestamp = scrape.Timestamp
+	response.Season = scrape.Season
+	response.Region = scrape.Region
+	return response, nil
+}
+
+// getResponse gets the data of every page for the specified region and season
+func (b *Leaderboard) getResponse(ctx context.Context, region string, season int, now time.Time) (*Response, error) {
+	response, err := b.getPage(ctx, region, season, 1, now)
+	if err != nil {
+		return response, err
+	}
+	for page := 2; page <= response.Data.Pages; page++ {
+		res, err := b.getPage(ctx, region, season, page, now)
+		if err != nil {
+			return response, err
+		}
+		response.Data.Merge(&res.Data)
+	}
+	return response, nil
+}
+
+// getPage gets the data for the specified region, season and page
+// retries with the site's policy, pages of a scrape at now are archived
+// a worker from the pool is only held during a request, not the backoff
+func (b *Leaderboard) getPage(ctx context.Context, region string, season, page int, now time.Time) (*Response, error) {
+	var response = b.newResponse()
+	b.mu.Lock()
+	var url = fmt.Sprintf(b.URL, region, season, page)
+	var retry = b.Retry
+	b.mu.Unlock()
+	err := retry.Do(ctx, func() error {
+		b.Sc.acquire()
+		body, err := b.Fetcher.Fetch(ctx, url)
+		b.Sc.release()
+		if err != nil {
+			return err
+		}
+		b.Logger.Debugf("[%s] Fetched region %s season %d page %d", b.Name(), region, season, page)
+		b.Sc.archive(b.Name(), region, season, page, now, body)
+		response = b.newResponse()
+		return json.Unmarshal(body, response)
+	})
+	if err != nil {
+		return response, fmt.Errorf("page %d: %w", page, err)
+	}
+	return response, nil
+}
+
+// loadSnapshots rebuilds the last two snapshots of a region
+// from the database so a restart continues the existing points
+func (b *Leaderboard) loadSnapshots(region string, season int) error {
+	curr, prev, err := b.Db.LatestSnapshots(b.Mode.Table, season, region)
+	if err != nil || curr == nil {
+		return err
+	}
+	b.CurrSnapshots[region] = b.fromSnapshot(curr)
+	b.PrevSnapshots[region] = b.fromSnapshot(prev)
+	return nil
+}
+
+// newResponse makes an empty response to parse a page of the leaderboard into
+func (b *Leaderboard) newResponse() *Response {
+	return &Response{
+		Data: Data{rated: b.Mode.Rated, scored: b.Mode.Scored},
+		Meta: Meta{id: b.Mode.ID},
+	}
+}
+
+// fromSnapshot converts a stored snapshot into a response
+func (b *Leaderboard) fromSnapshot(s *Snapshot) *Response {
+	var res = &Response{Timestamp: s.Timestamp, Season: s.Season, Region: s.Region}
+	res.Data.Rows = make(map[string]Row)
+	for _, p := range s.Points {
+		res.Data.Rows[p.Name] = Row{Name: p.Name, Rank: p.Rank, Rating: p.Rating, Score: p.Score}
+	}
+	return res
+}
+
+// matchDuplicates keeps the rows of duplicate account names attached
+// to their history by matching them against the last snapshot
+func (b *Leaderboard) matchDuplicates(res *Response) error {
+	curr := b.CurrSnapshots[res.Region]
+	if curr == nil {
+		return nil
+	}
+	var prev = make(map[string][]identity)
+	var next = make(map[string][]identity)
+	for _, row := range curr.Data.Rows {
+		base := baseName(row.Name)
+		prev[base] = append(prev[base], identity{Label: row.Name, Rank: row.Rank, Rating: row.Rating, Score: row.Score})
+	}
+	for _, row := range res.Data.Rows {
+		base := baseName(row.Name)
+		next[base] = append(next[base], identity{Label: row.Name, Rank: row.Rank, Rating: row.Rating, Score: row.Score})
+	}
+	var rows = make(map[string]Row, len(res.Data.Rows))
+	res.Data.Matches = make(map[string]float64)
+	for base, ids := range next {
+		var stored []string
+		if needsStored(prev[base], ids) {
+			var err error
+			if stored, err = b.storedLabels(res, base); err != nil {
+				return err
+			}
+		}
+		labels, confidence := matchIdentities(base, prev[base], ids, stored)
+		for i, id := range ids {
+			row := res.Data.Rows[id.Label]
+			row.Name = labels[i]
+			rows[row.Name] = row
+			if confidence[i] < 1 {
+				res.Data.Matches[row.Name] = confidence[i]
+			}
+		}
+	}
+	res.Data.Rows = rows
+	return nil
+}
+
+// storedLabels lists every label of a name stored in the season and region
+func (b *Leaderboard) storedLabels(res *Response, base string) ([]string, error) {
+	var labels = make([]string, 0)
+	var seen = make(map[string]bool)
+	err := b.Db.Points(b.Mode.Table, PointFilter{Season: res.Season, Region: res.Region, Name: base}, func(p *Point) error {
+		if !seen[p.Name] && baseName(p.Name) == base {
+			seen[p.Name] = true
+			labels = append(labels, p.Name)
+		}
+		return nil
+	})
+	return labels, err
+}
+
+// newPlayers lists the names of a response missing from the last snapshot
+// players of the last snapshot are stored already
+func (b *Leaderboard) newPlayers(res *Response) []string {
+	var names = make([]string, 0)
+	var curr = b.CurrSnapshots[res.Region]
+	for name := range res.Data.Rows {
+		if curr != nil {
+			if _, ok := curr.Data.Rows[name]; ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// saveDifferences compares a snapshot to the last snapshot
+// and saves the differences into database in a single batch
+func (b *Leaderboard) saveDifferences(res *Response) (new, old int, err error) {
+	var ok bool
+	if err = b.matchDuplicates(res); err != nil {
+		return
+	}
+	if err = b.Db.AddPlayers(res.Region, b.newPlayers(res)); err != nil {
+		return
+	}
+	batch, err := b.Db.Begin(b.Mode.Table)
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			batch.Rollback()
+			return
+		}
+		err = batch.Commit()
+	}()
+	for _, newR := range res.Data.Rows {
+		var curR, oldR Row
+
+		// First scrape should always make new points
+		if b.CurrSnapshots[res.Region] == nil {
+			if err = b.newPoint(batch, res, &newR, res.Timestamp); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Getting info from last snapshot
+		if curR, ok = b.CurrSnapshots[res.Region].Data.Rows[newR.Name]; !ok {
+			if err = b.newPoint(batch, res, &newR, res.Timestamp); err != nil {
+				return
+			}
+			continue
+		}
+		// A rank already held in the last snapshot was first seen there
+		first := res.Timestamp
+		if newR == curR {
+			first = b.CurrSnapshots[res.Region].Timestamp
+		}
+		// Getting info from one before snapshot
+		if oldR, ok = b.PrevSnapshots[res.Region].Data.Rows[newR.Name]; !ok {
+			if err = b.newPoint(batch, res, &newR, first); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Comparing rank, rating and score are always zero when unused
+		if newR.Rank != curR.Rank || newR.Rank != oldR.Rank {
+			if err = b.newPoint(batch, res, &newR, first); err != nil {
+				return
+			}
+			continue
+		}
+		if newR.Rating != curR.Rating || newR.Rating != oldR.Rating || newR.Score != curR.Score || newR.Score != oldR.Score {
+			if err = b.newPoint(batch, res, &newR, first); err != nil {
+				return
+			}
+			continue
+		}
+
+		// If all failed, update the point
+		if err = b.updatePoint(batch, res, &newR); err != nil {
+			return
+		}
+		old++
+	}
+	new = len(res.Data.Rows) - old
+	return
+}
+
+func (b *Leaderboard) newPoint(batch Batch, res *Response, p *Row, first int64) error {
+	point := b.toPoint(p, res.Timestamp, res.Season, res.Region)
+	point.FirstSeen = first
+	point.Confidence = res.Data.confidence(p.Name)
+	return batch.NewPoint(&point)
+}
+
+func (b *Leaderboard) updatePoint(batch Batch, res *Response, p *Row) error {
+	point := b.toPoint(p, res.Timestamp, res.Season, res.Region)
+	return batch.UpdatePoint(&point)
+}
+
+// toPoint converts a row into a stored point
+func (b *Leaderboard) toPoint(p *Row, t int64, season int, region string) Point {
+	return Point{FirstSeen: t, Timestamp: t, Season: season, Region: region, Name: p.Name, Rank: p.Rank, Rating: p.Rating, Score: p.Score, Confidence: 1}
+}
@@ -0,0 +1,162 @@
+package hsleaderboards
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// Response is a single page of a leaderboard
+type Response struct {
+	Timestamp int64
+	Season    int    `json:"seasonId"`
+	Region    string `json:"region"`
+	Data      Data   `json:"leaderboard"`
+	Meta      Meta   `json:"metaData"`
+}
+
+// Meta lists the seasons of a leaderboard and when they started
+type Meta struct {
+	Latest  int
+	Seasons []int
+	Starts  map[int]time.Time
+	id      string
+}
+
+// Data holds the rows of a leaderboard, keyed by unique name
+type Data struct {
+	ID      string
+	Pages   int
+	Rows    map[string]Row
+	Matches map[string]float64
+	list    []Row
+	names   map[string]int
+	rated   bool
+	scored  bool
+}
+
+// Row is a single entry of a leaderboard
+// Rating is always zero on leaderboards without rating
+// and Score on leaderboards without score
+type Row struct {
+	Name   string  `json:"accountid"`
+	Rank   int     `json:"rank"`
+	Rating int     `json:"rating"`
+	Score  float64 `json:"-"`
+}
+
+// rankRow is a single entry of a leaderboard without rating
+type rankRow struct {
+	Name string `json:"accountid"`
+	Rank int    `json:"rank"`
+}
+
+// scoreRow is a single entry of a leaderboard rated by a fraction
+type scoreRow struct {
+	Name  string  `json:"accountid"`
+	Rank  int     `json:"rank"`
+	Score float64 `json:"rating"`
+}
+
+func (receiver *Data) UnmarshalJSON(data []byte) error {
+	var jsonStr = string(data)
+	var rowsData = gjson.Get(jsonStr, "rows").String()
+	receiver.ID = gjson.Get(jsonStr, "leaderboard_id").String()
+	receiver.Pages = int(gjson.Get(jsonStr, "pagination.totalPages").Int())
+	receiver.Rows = make(map[string]Row)
+	receiver.names = make(map[string]int)
+	receiver.list = make([]Row, 0)
+	if receiver.rated {
+		err := json.Unmarshal([]byte(rowsData), &receiver.list)
+		if err != nil {
+			return err
+		}
+	} else if receiver.scored {
+		var rows []scoreRow
+		err := json.Unmarshal([]byte(rowsData), &rows)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			receiver.list = append(receiver.list, Row{Name: row.Name, Rank: row.Rank, Score: row.Score})
+		}
+	} else {
+		// The rating of leaderboards without rating is never read,
+		// whatever shape it has
+		var rows []rankRow
+		err := json.Unmarshal([]byte(rowsData), &rows)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			receiver.list = append(receiver.list, Row{Name: row.Name, Rank: row.Rank})
+		}
+	}
+	receiver.addRows(receiver.list)
+	return nil
+}
+
+// Merge adds the rows of another page to the data
+func (receiver *Data) Merge(page *Data) {
+	receiver.addRows(page.list)
+}
+
+// addRows adds rows to the data, renaming duplicate names
+func (receiver *Data) addRows(rows []Row) {
+	for _, row := range rows {
+		if val, ok := receiver.names[row.Name]; ok {
+			receiver.names[row.Name] = val + 1
+			row.Name = fmt.Sprintf("%s|%d", row.Name, val+1)
+		} else {
+			receiver.names[row.Name] = 1
+		}
+		receiver.Rows[row.Name] = row
+	}
+}
+
+func (receiver *Meta) UnmarshalJSON(data []byte) error {
+	var jsonStr = string(data)
+	var seasonsData = gjson.Get(jsonStr, receiver.id+".seasonsWithStartDate")
+	receiver.Starts = make(map[int]time.Time)
+	seasonsData.ForEach(func(season, start gjson.Result) bool {
+		val, err := strconv.Atoi(season.String())
+		if err != nil {
+			return true
+		}
+		if val > receiver.Latest {
+			receiver.Latest = val
+		}
+		receiver.Seasons = append(receiver.Seasons, val)
+		if parsed, err := time.Parse(time.RFC3339, start.String()); err == nil {
+			receiver.Starts[val] = parsed
+		}
+		return true
+	})
+	sort.Ints(receiver.Seasons)
+	return nil
+}
+
+// End returns when a season ended, which is when the next one started
+// the latest season has not ended yet
+func (receiver *Meta) End(season int) (time.Time, bool) {
+	for _, next := range receiver.Seasons {
+		if next > season {
+			start, ok := receiver.Starts[next]
+			return start, ok
+		}
+	}
+	return time.Time{}, false
+}
+
+// confidence returns how certain the match of a row to its history is
+// rows that were not matched are certain
+func (receiver *Data) confidence(name string) float64 {
+	if val, ok := receiver.Matches[name]; ok {
+		return val
+	}
+	return 1
+}
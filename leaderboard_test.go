@@ -0,0 +1,97 @@
+package hsleaderboards_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	hs "hsleaderboards"
+)
+
+// fixtures serves the pages in testdata, named <table>_page<page>.json
+type fixtures struct {
+	table string
+}
+
+func (f *fixtures) Fetch(ctx context.Context, raw string) ([]byte, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join("testdata", fmt.Sprintf("%s_page%s.json", f.table, u.Query().Get("page"))))
+}
+
+// scrapeFixtures scrapes a mode from its fixtures into a fresh database
+func scrapeFixtures(t *testing.T, mode hs.Mode, region string) (*hs.Leaderboard, hs.Storage) {
+	t.Helper()
+	_, db, sc := setup(t)
+	site := hs.MakeLeaderboard(mode, &fixtures{table: mode.Table})
+	site.Regions = []string{region}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return site, db
+}
+
+func TestArenaFixtures(t *testing.T) {
+	site, db := scrapeFixtures(t, hs.Arena, "US")
+	if site.LatestSeason != 46 {
+		t.Fatalf("LatestSeason = %d, want 46", site.LatestSeason)
+	}
+	curr, _, err := db.LatestSnapshots("arena", 46, "US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var points = make(map[string]hs.Point)
+	for _, p := range curr.Points {
+		if p.Rating != 0 {
+			t.Errorf("%s has rating %d, arena is stored without rating", p.Name, p.Rating)
+		}
+		points[p.Name] = p
+	}
+	want := map[string]hs.Point{
+		"Arenamaster":   {Rank: 1, Score: 8.9},
+		"Drafter":       {Rank: 2, Score: 8.45},
+		"Drafter|2":     {Rank: 3, Score: 8.4},
+		"Pickorder":     {Rank: 4, Score: 8.2},
+		"Arenamaster|2": {Rank: 5, Score: 7.95},
+	}
+	if len(points) != len(want) {
+		t.Fatalf("got %d players, want %d", len(points), len(want))
+	}
+	for name, w := range want {
+		if p := points[name]; p.Rank != w.Rank || p.Score != w.Score {
+			t.Errorf("%s = rank %d score %g, want rank %d score %g", name, p.Rank, p.Score, w.Rank, w.Score)
+		}
+	}
+}
+
+func TestBattlegroundsDuosFixtures(t *testing.T) {
+	site, db := scrapeFixtures(t, hs.BattlegroundsDuos, "EU")
+	if site.LatestSeason != 9 {
+		t.Fatalf("LatestSeason = %d, want 9", site.LatestSeason)
+	}
+	curr, _, err := db.LatestSnapshots("battlegrounds_duos", 9, "EU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var points = make(map[string]hs.Point)
+	for _, p := range curr.Points {
+		points[p.Name] = p
+	}
+	want := map[string][2]int{"Bobsbuddy": {1, 14210}, "Tavernkeep": {2, 14050}, "Bobsbuddy|2": {3, 13990}}
+	if len(points) != len(want) {
+		t.Fatalf("got %d players, want %d", len(points), len(want))
+	}
+	for name, w := range want {
+		if p := points[name]; p.Rank != w[0] || p.Rating != w[1] {
+			t.Errorf("%s = rank %d rating %d, want rank %d rating %d", name, p.Rank, p.Rating, w[0], w[1])
+		}
+	}
+}
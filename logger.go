@@ -0,0 +1,79 @@
+package hsleaderboards
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the lowest severity a Logger prints
+type Level int32
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprintf("level(%d)", int32(l))
+	}
+	return levelNames[l]
+}
+
+// ParseLevel parses a level name, ignoring case
+func ParseLevel(name string) (Level, error) {
+	for i, level := range levelNames {
+		if strings.EqualFold(level, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q, expected one of %s", name, strings.Join(levelNames, ", "))
+}
+
+// Logger is a log.Logger with a level that can change while scraping
+// Printf and Println log at info level
+type Logger struct {
+	*log.Logger
+	level int32
+}
+
+func MakeLogger(logger *log.Logger, level Level) *Logger {
+	return &Logger{Logger: logger, level: int32(level)}
+}
+
+// Level returns the current level of the logger
+func (l *Logger) Level() Level {
+	return Level(atomic.LoadInt32(&l.level))
+}
+
+// SetLevel changes the level of the logger, safe to call while logging
+func (l *Logger) SetLevel(level Level) {
+	atomic.StoreInt32(&l.level, int32(level))
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if l.Level() <= LevelDebug {
+		l.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *Logger) Printf(format string, v ...interface{}) {
+	if l.Level() <= LevelInfo {
+		l.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *Logger) Println(v ...interface{}) {
+	if l.Level() <= LevelInfo {
+		l.Output(2, fmt.Sprintln(v...))
+	}
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.Output(2, fmt.Sprintf(format, v...))
+}
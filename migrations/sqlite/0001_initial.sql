@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS "seasons" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"mode"      TEXT NOT NULL,
+	"endedSeasonId"	INTEGER NOT NULL,
+	"startedSeasonId"	INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS "standard" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+    "name"      TEXT NOT NULL,
+    "rank" 	    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ix_std_name_timestamp"
+ON standard(seasonId, name, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS "wild" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+    "name"      TEXT NOT NULL,
+    "rank" 	    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ix_wld_name_timestamp"
+ON wild(seasonId, name, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS "classic" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+    "name"      TEXT NOT NULL,
+    "rank" 	    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ix_cls_name_timestamp"
+ON classic(seasonId, name, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS "merceneries" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+    "name"      TEXT NOT NULL,
+    "rank" 	    INTEGER NOT NULL,
+    "rating" 	INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ix_mrc_name_timestamp"
+ON merceneries(seasonId, name, timestamp DESC);
+
+CREATE TABLE IF NOT EXISTS "battlegrounds" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"timestamp"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+    "name"      TEXT NOT NULL,
+    "rank" 	    INTEGER NOT NULL,
+    "rating" 	INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "ix_bgs_name_timestamp"
+ON battlegrounds(seasonId, name, timestamp DESC);
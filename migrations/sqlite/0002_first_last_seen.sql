@@ -0,0 +1,99 @@
+-- Every point keeps when its rank was first and last seen
+-- first_seen is rebuilt from the runs of points with the same rank
+
+ALTER TABLE standard RENAME COLUMN "timestamp" TO last_seen;
+ALTER TABLE standard ADD COLUMN first_seen INTEGER NOT NULL DEFAULT 0;
+UPDATE standard
+SET first_seen = islands.first_seen
+FROM (
+    SELECT rowid, MIN(last_seen) OVER (PARTITION BY seasonId, region, name, island) AS first_seen
+    FROM (
+        SELECT rowid, last_seen, seasonId, region, name,
+            SUM(changed) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid) AS island
+        FROM (
+            SELECT rowid, last_seen, seasonId, region, name,
+                CASE WHEN rank IS LAG(rank) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                THEN 0 ELSE 1 END AS changed
+            FROM standard
+        )
+    )
+) AS islands
+WHERE standard.rowid = islands.rowid;
+
+ALTER TABLE wild RENAME COLUMN "timestamp" TO last_seen;
+ALTER TABLE wild ADD COLUMN first_seen INTEGER NOT NULL DEFAULT 0;
+UPDATE wild
+SET first_seen = islands.first_seen
+FROM (
+    SELECT rowid, MIN(last_seen) OVER (PARTITION BY seasonId, region, name, island) AS first_seen
+    FROM (
+        SELECT rowid, last_seen, seasonId, region, name,
+            SUM(changed) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid) AS island
+        FROM (
+            SELECT rowid, last_seen, seasonId, region, name,
+                CASE WHEN rank IS LAG(rank) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                THEN 0 ELSE 1 END AS changed
+            FROM wild
+        )
+    )
+) AS islands
+WHERE wild.rowid = islands.rowid;
+
+ALTER TABLE classic RENAME COLUMN "timestamp" TO last_seen;
+ALTER TABLE classic ADD COLUMN first_seen INTEGER NOT NULL DEFAULT 0;
+UPDATE classic
+SET first_seen = islands.first_seen
+FROM (
+    SELECT rowid, MIN(last_seen) OVER (PARTITION BY seasonId, region, name, island) AS first_seen
+    FROM (
+        SELECT rowid, last_seen, seasonId, region, name,
+            SUM(changed) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid) AS island
+        FROM (
+            SELECT rowid, last_seen, seasonId, region, name,
+                CASE WHEN rank IS LAG(rank) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                THEN 0 ELSE 1 END AS changed
+            FROM classic
+        )
+    )
+) AS islands
+WHERE classic.rowid = islands.rowid;
+
+ALTER TABLE merceneries RENAME COLUMN "timestamp" TO last_seen;
+ALTER TABLE merceneries ADD COLUMN first_seen INTEGER NOT NULL DEFAULT 0;
+UPDATE merceneries
+SET first_seen = islands.first_seen
+FROM (
+    SELECT rowid, MIN(last_seen) OVER (PARTITION BY seasonId, region, name, island) AS first_seen
+    FROM (
+        SELECT rowid, last_seen, seasonId, region, name,
+            SUM(changed) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid) AS island
+        FROM (
+            SELECT rowid, last_seen, seasonId, region, name,
+                CASE WHEN rank IS LAG(rank) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                    AND rating IS LAG(rating) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                THEN 0 ELSE 1 END AS changed
+            FROM merceneries
+        )
+    )
+) AS islands
+WHERE merceneries.rowid = islands.rowid;
+
+ALTER TABLE battlegrounds RENAME COLUMN "timestamp" TO last_seen;
+ALTER TABLE battlegrounds ADD COLUMN first_seen INTEGER NOT NULL DEFAULT 0;
+UPDATE battlegrounds
+SET first_seen = islands.first_seen
+FROM (
+    SELECT rowid, MIN(last_seen) OVER (PARTITION BY seasonId, region, name, island) AS first_seen
+    FROM (
+        SELECT rowid, last_seen, seasonId, region, name,
+            SUM(changed) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid) AS island
+        FROM (
+            SELECT rowid, last_seen, seasonId, region, name,
+                CASE WHEN rank IS LAG(rank) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                    AND rating IS LAG(rating) OVER (PARTITION BY seasonId, region, name ORDER BY last_seen, rowid)
+                THEN 0 ELSE 1 END AS changed
+            FROM battlegrounds
+        )
+    )
+) AS islands
+WHERE battlegrounds.rowid = islands.rowid;
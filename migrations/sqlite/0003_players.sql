@@ -0,0 +1,78 @@
+-- Players are shared by every game mode, identified by region and name
+
+CREATE TABLE IF NOT EXISTS "players" (
+	"id"        INTEGER PRIMARY KEY AUTOINCREMENT,
+	"region"    TEXT NOT NULL,
+	"name"      TEXT NOT NULL,
+	UNIQUE (region, name)
+);
+INSERT INTO players (region, name)
+SELECT region, name FROM standard
+UNION
+SELECT region, name FROM wild
+UNION
+SELECT region, name FROM classic
+UNION
+SELECT region, name FROM merceneries
+UNION
+SELECT region, name FROM battlegrounds;
+
+ALTER TABLE standard ADD COLUMN player_id INTEGER REFERENCES players(id);
+UPDATE standard
+SET player_id = (
+    SELECT id
+    FROM players
+    WHERE players.region = standard.region AND players.name = standard.name
+);
+DROP INDEX IF EXISTS "ix_std_name_timestamp";
+ALTER TABLE standard DROP COLUMN name;
+CREATE INDEX IF NOT EXISTS "ix_std_player_last_seen"
+ON standard(seasonId, region, player_id, last_seen DESC);
+
+ALTER TABLE wild ADD COLUMN player_id INTEGER REFERENCES players(id);
+UPDATE wild
+SET player_id = (
+    SELECT id
+    FROM players
+    WHERE players.region = wild.region AND players.name = wild.name
+);
+DROP INDEX IF EXISTS "ix_wld_name_timestamp";
+ALTER TABLE wild DROP COLUMN name;
+CREATE INDEX IF NOT EXISTS "ix_wld_player_last_seen"
+ON wild(seasonId, region, player_id, last_seen DESC);
+
+ALTER TABLE classic ADD COLUMN player_id INTEGER REFERENCES players(id);
+UPDATE classic
+SET player_id = (
+    SELECT id
+    FROM players
+    WHERE players.region = classic.region AND players.name = classic.name
+);
+DROP INDEX IF EXISTS "ix_cls_name_timestamp";
+ALTER TABLE classic DROP COLUMN name;
+CREATE INDEX IF NOT EXISTS "ix_cls_player_last_seen"
+ON classic(seasonId, region, player_id, last_seen DESC);
+
+ALTER TABLE merceneries ADD COLUMN player_id INTEGER REFERENCES players(id);
+UPDATE merceneries
+SET player_id = (
+    SELECT id
+    FROM players
+    WHERE players.region = merceneries.region AND players.name = merceneries.name
+);
+DROP INDEX IF EXISTS "ix_mrc_name_timestamp";
+ALTER TABLE merceneries DROP COLUMN name;
+CREATE INDEX IF NOT EXISTS "ix_mrc_player_last_seen"
+ON merceneries(seasonId, region, player_id, last_seen DESC);
+
+ALTER TABLE battlegrounds ADD COLUMN player_id INTEGER REFERENCES players(id);
+UPDATE battlegrounds
+SET player_id = (
+    SELECT id
+    FROM players
+    WHERE players.region = battlegrounds.region AND players.name = battlegrounds.name
+);
+DROP INDEX IF EXISTS "ix_bgs_name_timestamp";
+ALTER TABLE battlegrounds DROP COLUMN name;
+CREATE INDEX IF NOT EXISTS "ix_bgs_player_last_seen"
+ON battlegrounds(seasonId, region, player_id, last_seen DESC);
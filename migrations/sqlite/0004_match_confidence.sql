@@ -0,0 +1,7 @@
+-- Points of duplicate account names are matched to a history by rank
+-- and rating continuity, confidence is how certain that match was
+ALTER TABLE standard ADD COLUMN confidence REAL NOT NULL DEFAULT 1;
+ALTER TABLE wild ADD COLUMN confidence REAL NOT NULL DEFAULT 1;
+ALTER TABLE classic ADD COLUMN confidence REAL NOT NULL DEFAULT 1;
+ALTER TABLE merceneries ADD COLUMN confidence REAL NOT NULL DEFAULT 1;
+ALTER TABLE battlegrounds ADD COLUMN confidence REAL NOT NULL DEFAULT 1;
@@ -0,0 +1,28 @@
+-- Arena and Battlegrounds Duos leaderboards
+
+CREATE TABLE IF NOT EXISTS "arena" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"first_seen"	INTEGER NOT NULL,
+	"last_seen"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+	"player_id" INTEGER NOT NULL REFERENCES players(id),
+	"rank" 	    INTEGER NOT NULL,
+	"confidence"	REAL NOT NULL DEFAULT 1
+);
+CREATE INDEX IF NOT EXISTS "ix_arn_player_last_seen"
+ON arena(seasonId, region, player_id, last_seen DESC);
+
+CREATE TABLE IF NOT EXISTS "battlegrounds_duos" (
+	"rowid" INTEGER PRIMARY KEY AUTOINCREMENT,
+	"first_seen"	INTEGER NOT NULL,
+	"last_seen"	INTEGER NOT NULL,
+	"seasonId"	INTEGER NOT NULL,
+	"region"    TEXT NOT NULL,
+	"player_id" INTEGER NOT NULL REFERENCES players(id),
+	"rank" 	    INTEGER NOT NULL,
+	"rating" 	INTEGER NOT NULL,
+	"confidence"	REAL NOT NULL DEFAULT 1
+);
+CREATE INDEX IF NOT EXISTS "ix_bgd_player_last_seen"
+ON battlegrounds_duos(seasonId, region, player_id, last_seen DESC);
@@ -0,0 +1,2 @@
+-- Arena rates by the average wins of the best runs, a fraction
+ALTER TABLE arena ADD COLUMN score REAL NOT NULL DEFAULT 0;
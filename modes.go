@@ -0,0 +1,91 @@
+package hsleaderboards
+
+// DefaultBaseURL is where the leaderboard api is served
+const DefaultBaseURL = "https://playhearthstone.com"
+
+// Mode describes a leaderboard of the api and where it is stored
+// Path takes the region, season and page, Season is the season
+// asked for before the latest one is known
+// Scored modes rate by a fraction, stored as score instead of a rating
+type Mode struct {
+	Name   string
+	ID     string
+	Table  string
+	Rated  bool
+	Scored bool
+	Path   string
+	Season int
+}
+
+var Standard = Mode{
+	Name:   "Standard",
+	ID:     "STD",
+	Table:  "standard",
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=STD&seasonId=%d&page=%d",
+	Season: 104,
+}
+
+var Wild = Mode{
+	Name:   "Wild",
+	ID:     "WLD",
+	Table:  "wild",
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=WLD&seasonId=%d&page=%d",
+	Season: 104,
+}
+
+var Classic = Mode{
+	Name:   "Classic",
+	ID:     "CLS",
+	Table:  "classic",
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=CLS&seasonId=%d&page=%d",
+	Season: 104,
+}
+
+var Battlegrounds = Mode{
+	Name:   "Battlegrounds",
+	ID:     "BG",
+	Table:  "battlegrounds",
+	Rated:  true,
+	Path:   "/en-gb/api/community/leaderboardsData?region=%s&leaderboardId=BG&seasonId=%d&page=%d",
+	Season: 6,
+}
+
+var Merceneries = Mode{
+	Name:   "Merceneries",
+	ID:     "MRC",
+	Table:  "merceneries",
+	Rated:  true,
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=MRC&seasonId=%d&page=%d",
+	Season: 7,
+}
+
+// Arena ranks by the average wins of the best runs, which is a
+// fraction, so it is stored as score
+var Arena = Mode{
+	Name:   "Arena",
+	ID:     "arena",
+	Table:  "arena",
+	Scored: true,
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=arena&seasonId=%d&page=%d",
+	Season: 45,
+}
+
+var BattlegroundsDuos = Mode{
+	Name:   "BattlegroundsDuos",
+	ID:     "battlegroundsduo",
+	Table:  "battlegrounds_duos",
+	Rated:  true,
+	Path:   "/en-us/api/community/leaderboardsData?region=%s&leaderboardId=battlegroundsduo&seasonId=%d&page=%d",
+	Season: 8,
+}
+
+// Modes lists every leaderboard the scraper supports
+var Modes = []Mode{
+	Standard,
+	Wild,
+	Battlegrounds,
+	Merceneries,
+	Classic,
+	Arena,
+	BattlegroundsDuos,
+}
@@ -0,0 +1,74 @@
+package hsleaderboards
+
+import (
+	"database/sql"
+	"embed"
+	"log"
+
+	_ "github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var pg_migrations embed.FS
+
+//go:embed queries/postgres/lb_new.sql
+var pg_leaderboard_new string
+
+//go:embed queries/postgres/lb_update.sql
+var pg_leaderboard_update string
+
+//go:embed queries/postgres/lb_latest.sql
+var pg_leaderboard_latest string
+
+//go:embed queries/postgres/lb_exists.sql
+var pg_leaderboard_exists string
+
+//go:embed queries/postgres/lb_points.sql
+var pg_leaderboard_points string
+
+//go:embed queries/postgres/lb_stats.sql
+var pg_leaderboard_stats string
+
+//go:embed queries/postgres/se_new.sql
+var pg_seasons_new string
+
+//go:embed queries/postgres/pl_new.sql
+var pg_player_new string
+
+//go:embed queries/postgres/sv_create.sql
+var pg_version_create string
+
+//go:embed queries/postgres/sv_new.sql
+var pg_version_new string
+
+//go:embed queries/postgres/sv_current.sql
+var pg_version_current string
+
+//go:embed queries/postgres/sv_exists.sql
+var pg_version_exists string
+
+var postgresDialect = &sqlDialect{
+	leaderboards: sqlQueries{
+		new:    pg_leaderboard_new,
+		update: pg_leaderboard_update,
+		latest: pg_leaderboard_latest,
+		exists: pg_leaderboard_exists,
+		points: pg_leaderboard_points,
+		stats:  pg_leaderboard_stats,
+	},
+	seasonsNew:     pg_seasons_new,
+	playerNew:      pg_player_new,
+	versionCreate:  pg_version_create,
+	versionNew:     pg_version_new,
+	versionCurrent: pg_version_current,
+	versionExists:  pg_version_exists,
+	migrations:     mustSub(pg_migrations, "migrations/postgres"),
+}
+
+func MakePostgres(logger *log.Logger, cfg *Config) (*SQLStorage, error) {
+	db, err := sql.Open("postgres", cfg.DBURL)
+	if err != nil {
+		return nil, err
+	}
+	return makeSQLStorage(logger, cfg, db, postgresDialect), nil
+}
@@ -0,0 +1,98 @@
+package hsleaderboards_test
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	hs "hsleaderboards"
+)
+
+// TestPostgres runs the storage tests against Postgres when
+// HS_TEST_POSTGRES_URL is set, every test gets its own schema:
+//
+//	docker run --rm -d -p 5432:5432 -e POSTGRES_PASSWORD=hs postgres
+//	HS_TEST_POSTGRES_URL="postgres://postgres:hs@localhost:5432/postgres?sslmode=disable" go test -run Postgres
+func TestPostgres(t *testing.T) {
+	url := os.Getenv("HS_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("HS_TEST_POSTGRES_URL is not set")
+	}
+	openStorage = func(t *testing.T, logger *log.Logger, cfg *hs.Config) *hs.SQLStorage {
+		return openPostgres(t, url, logger, cfg)
+	}
+	defer func() { openStorage = openSQLite }()
+
+	tests := []struct {
+		name string
+		test func(*testing.T)
+	}{
+		{"InitializeUsesLatestSeason", TestInitializeUsesLatestSeason},
+		{"ScrapeStoresEveryPage", TestScrapeStoresEveryPage},
+		{"ScrapeUpdatesStablePoints", TestScrapeUpdatesStablePoints},
+		{"RestartContinuesPoints", TestRestartContinuesPoints},
+		{"ScrapeKeepsDuplicateNamesApart", TestScrapeKeepsDuplicateNamesApart},
+		{"ScrapeRollsOverToNextSeason", TestScrapeRollsOverToNextSeason},
+		{"ScrapeNeverRollsBackToOlderSeason", TestScrapeNeverRollsBackToOlderSeason},
+		{"ScrapeReportsErrors", TestScrapeReportsErrors},
+		{"ScraperWithoutConcurrencyScrapes", TestScraperWithoutConcurrencyScrapes},
+		{"ReloadAddsAndRemovesSites", TestReloadAddsAndRemovesSites},
+		{"ReconfigureAddsRegions", TestReconfigureAddsRegions},
+		{"BackfillDatesSeasonsByTheirEnd", TestBackfillDatesSeasonsByTheirEnd},
+		{"PointsFilters", TestPointsFilters},
+		{"StatsBySeasonAndRegion", TestStatsBySeasonAndRegion},
+		{"NewPointNeedsStoredPlayer", TestNewPointNeedsStoredPlayer},
+		{"SchemaVersionDoesNotWrite", TestSchemaVersionDoesNotWrite},
+		{"MigrateKeepsLegacyPoints", TestMigrateKeepsLegacyPoints},
+		{"ReplayRebuildsScrapedPoints", TestReplayRebuildsScrapedPoints},
+		{"ReplaySkipsIncompleteScrapes", TestReplaySkipsIncompleteScrapes},
+		{"ArenaFixtures", TestArenaFixtures},
+		{"BattlegroundsDuosFixtures", TestBattlegroundsDuosFixtures},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, tt.test)
+	}
+}
+
+// openPostgres opens a fresh schema of the test database
+// the schema is dropped once the test is done
+func openPostgres(t *testing.T, url string, logger *log.Logger, cfg *hs.Config) *hs.SQLStorage {
+	t.Helper()
+	admin, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("hs_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP SCHEMA " + schema + " CASCADE"); err != nil {
+			t.Error(err)
+		}
+		admin.Close()
+	})
+	cfg.DBDriver = "postgres"
+	cfg.DBURL = withSearchPath(url, schema)
+	db, err := hs.MakePostgres(logger, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// withSearchPath adds a search_path to a url or key=value connection string
+func withSearchPath(url, schema string) string {
+	if !strings.HasPrefix(url, "postgres://") && !strings.HasPrefix(url, "postgresql://") {
+		return url + " search_path=" + schema
+	}
+	if strings.Contains(url, "?") {
+		return url + "&search_path=" + schema
+	}
+	return url + "?search_path=" + schema
+}
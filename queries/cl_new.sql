@@ -1,3 +0,0 @@
-INSERT INTO classic
-    (timestamp, seasonId, region, name, rank)
-VALUES(?,?,?,?,?);
\ No newline at end of file
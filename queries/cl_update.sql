@@ -1,10 +0,0 @@
-UPDATE classic
-SET timestamp = ?
-FROM (
-    SELECT rowid
-    FROM classic
-    WHERE seasonId = ? AND region = ? AND name = ?
-    ORDER BY timestamp desc
-    LIMIT 1
-) AS latest
-WHERE classic.rowid = latest.rowid;
\ No newline at end of file
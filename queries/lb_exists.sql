@@ -0,0 +1,5 @@
+SELECT EXISTS (
+    SELECT 1
+    FROM {{.Table}}
+    WHERE seasonId = ? AND region = ?
+);
\ No newline at end of file
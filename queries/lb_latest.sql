@@ -0,0 +1,9 @@
+SELECT last_seen, players.name, rank{{if .Rated}}, rating{{else if .Scored}}, score{{end}}, position
+FROM (
+    SELECT last_seen, player_id, rank{{if .Rated}}, rating{{else if .Scored}}, score{{end}},
+        ROW_NUMBER() OVER (PARTITION BY player_id ORDER BY last_seen DESC, rowid DESC) AS position
+    FROM {{.Table}}
+    WHERE seasonId = ? AND region = ?
+) AS latest
+JOIN players ON players.id = latest.player_id
+WHERE position <= 2;
\ No newline at end of file
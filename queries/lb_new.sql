@@ -0,0 +1,5 @@
+INSERT INTO {{.Table}}
+    (first_seen, last_seen, seasonId, region, player_id, rank{{if .Rated}}, rating{{else if .Scored}}, score{{end}}, confidence)
+SELECT ?1, ?2, ?3, ?4, players.id, ?6{{if or .Rated .Scored}}, ?7, ?8{{else}}, ?7{{end}}
+FROM players
+WHERE players.region = ?4 AND players.name = ?5;
\ No newline at end of file
@@ -0,0 +1,7 @@
+SELECT first_seen, last_seen, seasonId, {{.Table}}.region, players.name, rank{{if .Rated}}, rating{{else if .Scored}}, score{{end}}, confidence
+FROM {{.Table}}
+JOIN players ON players.id = {{.Table}}.player_id
+WHERE (?1 = 0 OR seasonId = ?1)
+    AND (?2 = '' OR {{.Table}}.region = ?2)
+    AND (?3 = '' OR players.name = ?3 OR substr(players.name, 1, length(?3) + 1) = ?3 || '|')
+ORDER BY {{.Table}}.rowid;
\ No newline at end of file
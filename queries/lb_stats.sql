@@ -0,0 +1,4 @@
+SELECT seasonId, region, COUNT(*), COUNT(DISTINCT player_id), MIN(first_seen), MAX(last_seen)
+FROM {{.Table}}
+GROUP BY seasonId, region
+ORDER BY seasonId, region;
\ No newline at end of file
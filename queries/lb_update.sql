@@ -0,0 +1,11 @@
+UPDATE {{.Table}}
+SET last_seen = ?
+FROM (
+    SELECT {{.Table}}.rowid
+    FROM {{.Table}}
+    JOIN players ON players.id = {{.Table}}.player_id
+    WHERE {{.Table}}.seasonId = ? AND {{.Table}}.region = ? AND players.name = ?
+    ORDER BY {{.Table}}.last_seen desc
+    LIMIT 1
+) AS latest
+WHERE {{.Table}}.rowid = latest.rowid;
\ No newline at end of file
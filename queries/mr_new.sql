@@ -1,3 +0,0 @@
-INSERT INTO merceneries
-    (timestamp, seasonId, region, name, rank, rating)
-VALUES(?,?,?,?,?,?);
\ No newline at end of file
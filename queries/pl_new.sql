@@ -0,0 +1,4 @@
+INSERT INTO players
+    (region, name)
+VALUES(?,?)
+ON CONFLICT (region, name) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,5 @@
+SELECT EXISTS (
+    SELECT 1
+    FROM {{.Table}}
+    WHERE "seasonId" = $1 AND region = $2
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+INSERT INTO {{.Table}}
+    (first_seen, last_seen, "seasonId", region, player_id, rank{{if .Rated}}, rating{{else if .Scored}}, score{{end}}, confidence)
+SELECT $1::BIGINT, $2::BIGINT, $3::INTEGER, $4::TEXT, players.id, $6::INTEGER{{if .Rated}}, $7::INTEGER, $8::DOUBLE PRECISION{{else if .Scored}}, $7::DOUBLE PRECISION, $8::DOUBLE PRECISION{{else}}, $7::DOUBLE PRECISION{{end}}
+FROM players
+WHERE players.region = $4 AND players.name = $5;
\ No newline at end of file
@@ -0,0 +1,10 @@
+UPDATE {{.Table}}
+SET last_seen = $1
+WHERE rowid = (
+    SELECT {{.Table}}.rowid
+    FROM {{.Table}}
+    JOIN players ON players.id = {{.Table}}.player_id
+    WHERE {{.Table}}."seasonId" = $2 AND {{.Table}}.region = $3 AND players.name = $4
+    ORDER BY {{.Table}}.last_seen desc
+    LIMIT 1
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+INSERT INTO players
+    (region, name)
+VALUES($1,$2)
+ON CONFLICT (region, name) DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO seasons
+    ("timestamp", mode, "endedSeasonId", "startedSeasonId")
+VALUES($1,$2,$3,$4);
\ No newline at end of file
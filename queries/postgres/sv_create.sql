@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS "schema_version" (
+	"version"   INTEGER PRIMARY KEY,
+	"name"      TEXT NOT NULL,
+	"timestamp"	BIGINT NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,3 @@
+SELECT COUNT(*)
+FROM information_schema.tables
+WHERE table_schema = current_schema() AND table_name = 'schema_version';
\ No newline at end of file
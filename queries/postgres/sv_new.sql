@@ -0,0 +1,3 @@
+INSERT INTO schema_version
+    (version, name, "timestamp")
+VALUES($1,$2,$3);
\ No newline at end of file
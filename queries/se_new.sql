@@ -0,0 +1,3 @@
+INSERT INTO seasons
+    (timestamp, mode, endedSeasonId, startedSeasonId)
+VALUES(?,?,?,?);
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO schema_version
+    (version, name, timestamp)
+VALUES(?,?,?);
\ No newline at end of file
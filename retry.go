@@ -0,0 +1,120 @@
+package hsleaderboards
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries failed requests with exponential backoff
+// Attempts counts the first try, a Retry-After from the server
+// takes precedence over the backoff but never waits past MaxDelay
+type RetryPolicy struct {
+	Attempts  int
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// DefaultRetry is the retry policy every site starts with
+var DefaultRetry = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: time.Second,
+	MaxDelay:  30 * time.Second,
+}
+
+// StatusError is returned for responses without a 2xx status
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d %s", e.Code, http.StatusText(e.Code))
+}
+
+// Do calls fn until it succeeds, fails permanently or runs out of attempts
+// the last error is returned
+func (p *RetryPolicy) Do(ctx context.Context, fn func() error) error {
+	var err error
+	var attempts = p.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(p.delay(attempt, err))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err = fn(); err == nil || !retryable(err) {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return fmt.Errorf("giving up after %d attempts: %w", attempts, err)
+}
+
+// delay returns the wait before an attempt
+// the backoff doubles every attempt and is jittered down to half
+func (p *RetryPolicy) delay(attempt int, err error) time.Duration {
+	var status *StatusError
+	if errors.As(err, &status) && status.RetryAfter > 0 {
+		if p.MaxDelay > 0 && status.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return status.RetryAfter
+	}
+	backoff := p.BaseDelay << uint(attempt-1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// retryable classifies an error as temporary
+// throttling, server errors and network errors are worth retrying,
+// client errors and unparsable responses are not
+func retryable(err error) bool {
+	var status *StatusError
+	var syntax *json.SyntaxError
+	var unmarshal *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &status):
+		return status.Code == http.StatusTooManyRequests ||
+			status.Code == http.StatusRequestTimeout ||
+			status.Code >= 500
+	case errors.As(err, &syntax), errors.As(err, &unmarshal):
+		return false
+	}
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header in seconds or as a date
+func parseRetryAfter(val string, now time.Time) time.Duration {
+	if val == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(val); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,75 @@
+package hsleaderboards
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryDelayBacksOff(t *testing.T) {
+	p := &RetryPolicy{Attempts: 5, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for attempt, max := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 4 * time.Second} {
+		d := p.delay(attempt, errors.New("network"))
+		if d < max/2 || d > max {
+			t.Errorf("attempt %d: delay %s out of [%s, %s]", attempt, d, max/2, max)
+		}
+	}
+}
+
+func TestRetryDelayUsesRetryAfter(t *testing.T) {
+	p := &RetryPolicy{Attempts: 3, BaseDelay: time.Second, MaxDelay: 4 * time.Second}
+	for retryAfter, want := range map[time.Duration]time.Duration{3 * time.Second: 3 * time.Second, time.Minute: 4 * time.Second} {
+		err := &StatusError{Code: http.StatusTooManyRequests, RetryAfter: retryAfter}
+		if d := p.delay(1, err); d != want {
+			t.Errorf("Retry-After %s: delay = %s, want %s", retryAfter, d, want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"soon":                          0,
+		"Fri, 01 Jan 2021 00:00:30 GMT": 30 * time.Second,
+		"Thu, 31 Dec 2020 23:59:00 GMT": 0,
+	}
+	for val, want := range cases {
+		if got := parseRetryAfter(val, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", val, got, want)
+		}
+	}
+}
+
+func TestRetryDoReportsLastError(t *testing.T) {
+	p := &RetryPolicy{Attempts: 3}
+	calls := 0
+	err := p.Do(context.Background(), func() error {
+		calls++
+		return &StatusError{Code: http.StatusServiceUnavailable}
+	})
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusServiceUnavailable {
+		t.Fatalf("got error %v, want status 503", err)
+	}
+	if calls != 3 {
+		t.Fatalf("got %d calls, want 3", calls)
+	}
+}
+
+func TestRetryDoStopsOnCancel(t *testing.T) {
+	p := &RetryPolicy{Attempts: 3, BaseDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := p.Do(ctx, func() error {
+		calls++
+		cancel()
+		return errors.New("network")
+	})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Fatalf("got error %v after %d calls, want context canceled after 1", err, calls)
+	}
+}
@@ -0,0 +1,88 @@
+package hsleaderboards
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestCronNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	cases := []struct {
+		name, expr, from, want string
+	}{
+		{"step", "*/15 * * * *", "2024-03-08 10:07", "2024-03-08 10:15"},
+		{"step wraps the hour", "*/15 * * * *", "2024-03-08 10:45", "2024-03-08 11:00"},
+		{"stepped range", "0 9-17/4 * * *", "2024-03-08 10:00", "2024-03-08 13:00"},
+		{"stepped range ends", "0 9-17/4 * * *", "2024-03-08 17:00", "2024-03-09 09:00"},
+		{"list", "5,35 * * * *", "2024-03-08 10:05", "2024-03-08 10:35"},
+		{"weekdays skip the weekend", "30 8 * * 1-5", "2024-03-08 09:00", "2024-03-11 08:30"},
+		{"day of week only", "0 0 * * 0", "2024-03-11 00:00", "2024-03-17 00:00"},
+		{"day of month or week, month first", "0 0 13 * 5", "2024-03-09 00:00", "2024-03-13 00:00"},
+		{"day of month or week, week first", "0 0 13 * 5", "2024-03-13 01:00", "2024-03-15 00:00"},
+		{"month without the day", "0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+		{"year rollover", "0 0 31 * *", "2024-12-31 00:00", "2025-01-31 00:00"},
+		{"single month", "0 12 1 2 *", "2024-03-01 00:00", "2025-02-01 12:00"},
+		{"leap day", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+	}
+	for _, c := range cases {
+		cron, err := parseCron(c.expr)
+		if err != nil {
+			t.Fatalf("%s: %s", c.name, err)
+		}
+		if got := cron.next(at(c.from)); !got.Equal(at(c.want)) {
+			t.Errorf("%s: next(%s) of %q = %s, want %s", c.name, c.from, c.expr, got.Format("2006-01-02 15:04"), c.want)
+		}
+	}
+}
+
+func TestParseCronRejectsInvalidFields(t *testing.T) {
+	for _, expr := range []string{
+		"* * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-a * * * *",
+	} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parsed invalid cron %q", expr)
+		}
+	}
+}
+
+func TestScheduleNextAddsJitter(t *testing.T) {
+	schedule, err := MakeSchedule(time.Minute, 10*time.Second, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		next := schedule.Next(now)
+		if next.Before(now.Add(time.Minute)) || !next.Before(now.Add(70*time.Second)) {
+			t.Fatalf("next = %s after now, want between 1m and 1m10s", next.Sub(now))
+		}
+	}
+}
+
+func TestLoadScheduleFallsBackToPositiveInterval(t *testing.T) {
+	cases := map[int]time.Duration{90: 90 * time.Second, 0: defaultInterval * time.Second, -1: defaultInterval * time.Second}
+	for global, want := range cases {
+		cfg := &Config{Interval: global, Modes: map[string]*ModeConfig{"wild": {Cron: "* * *"}}}
+		sc := MakeScraper(nil, log.New(ioutil.Discard, "", 0), cfg)
+		if schedule := sc.loadSchedule("Wild"); schedule.Interval != want {
+			t.Errorf("global %ds: interval = %s, want %s", global, schedule.Interval, want)
+		}
+	}
+}
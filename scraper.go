@@ -1,7 +1,11 @@
 package hsleaderboards
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -9,65 +13,463 @@ import (
 // it handles scheduling the different
 // site scraping functions
 type Scraper struct {
-	Sites    []Site
-	Db       *Database
-	Schedule *time.Ticker
-	Cfg      *Config
-	Logger   *log.Logger
+	Sites   []Site
+	Db      Storage
+	Cfg     *Config
+	Logger  *Logger
+	Archive *Archive
+	Pool    chan struct{}
+	states  map[Site]*siteState
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+	loops   sync.WaitGroup
+	started bool
+	mu      sync.Mutex
 }
 
 // Site is the interface every different game mode implements
 type Site interface {
 	Name() string
-	Initialize(*Scraper, *Database) error
-	Scrape() error
+	Initialize(context.Context, *Scraper, Storage) error
+	Scrape(context.Context) error
 }
 
-func MakeScraper(db *Database, logger *log.Logger, cfg *Config) *Scraper {
+// Backfiller is implemented by sites that can store past seasons
+type Backfiller interface {
+	Site
+	Backfill(ctx context.Context, regions []string) error
+}
+
+// Replayer is implemented by sites that can rebuild their points
+// from the raw response archive
+type Replayer interface {
+	Site
+	Replay(ctx context.Context, sc *Scraper, db Storage) error
+}
+
+// Reconfigurer is implemented by sites whose settings can change
+// while scraping, they take over the settings of a freshly made site
+type Reconfigurer interface {
+	Site
+	Reconfigure(from Site) error
+}
+
+// siteState keeps track of the schedule and health of a site
+// stop is closed when the site is removed, wake is signaled
+// when its schedule changes and active is set while it is launched
+type siteState struct {
+	Schedule    *Schedule
+	Initialized bool
+	Failures    int
+	Disabled    bool
+	active      bool
+	stop        chan struct{}
+	wake        chan struct{}
+}
+
+func MakeScraper(db Storage, logger *log.Logger, cfg *Config) *Scraper {
+	ctx, cancel := context.WithCancel(context.Background())
+	level, err := ParseLevel(cfg.LogLevel)
+	if err != nil {
+		level = LevelInfo
+	}
+	// An unbuffered pool would block the first request forever
+	var concurrency = cfg.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
 	return &Scraper{
-		Sites:    make([]Site, 0),
-		Db:       db,
-		Schedule: time.NewTicker(time.Duration(cfg.Interval) * time.Second),
-		Cfg:      cfg,
-		Logger:   logger,
+		Sites:  make([]Site, 0),
+		Db:     db,
+		Cfg:    cfg,
+		Logger: MakeLogger(logger, level),
+		Pool:   make(chan struct{}, concurrency),
+		states: make(map[Site]*siteState),
+		ctx:    ctx,
+		cancel: cancel,
 	}
 }
 
 // AddSite adds a gamemode scraper to the list
+// its schedule is loaded from the config
 func (sc *Scraper) AddSite(site Site) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.addSite(site, sc.loadSchedule(site.Name()))
+}
+
+// AddScheduledSite adds a gamemode scraper to the list
+// with its own schedule
+func (sc *Scraper) AddScheduledSite(site Site, schedule *Schedule) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.addSite(site, schedule)
+}
+
+// addSite adds a site and starts it right away if the scraper is running
+func (sc *Scraper) addSite(site Site, schedule *Schedule) {
+	state := &siteState{
+		Schedule: schedule,
+		stop:     make(chan struct{}),
+		wake:     make(chan struct{}, 1),
+	}
 	sc.Sites = append(sc.Sites, site)
+	sc.states[site] = state
+	if sc.started {
+		sc.launch(site, state)
+	}
 }
 
-func (sc *Scraper) initialize() {
-	for _, site := range sc.Sites {
-		err := site.Initialize(sc, sc.Db)
+// removeSite stops scheduling a site
+// a scrape in progress still finishes and saves
+func (sc *Scraper) removeSite(site Site) {
+	close(sc.states[site].stop)
+	delete(sc.states, site)
+	var sites = make([]Site, 0, len(sc.Sites))
+	for _, other := range sc.Sites {
+		if other != site {
+			sites = append(sites, other)
+		}
+	}
+	sc.Sites = sites
+}
+
+// loadSchedule loads the schedule of a site from the config
+// an invalid schedule falls back to the global interval, or the default one
+func (sc *Scraper) loadSchedule(name string) *Schedule {
+	schedule, err := sc.Cfg.LoadSchedule(name)
+	if err == nil {
+		return schedule
+	}
+	sc.Logger.Errorf("[Scraper] Invalid schedule for %s, using global interval, %s", name, err)
+	if schedule, err = MakeSchedule(time.Duration(sc.Cfg.Interval)*time.Second, 0, ""); err != nil {
+		sc.Logger.Errorf("[Scraper] Invalid global interval, using %ds, %s", defaultInterval, err)
+		schedule, _ = MakeSchedule(defaultInterval*time.Second, 0, "")
+	}
+	return schedule
+}
+
+// Reload applies a new config to the scraper, sites are matched by name:
+// new sites are added, missing ones stop after their current scrape
+// and the others take over their new schedule and settings,
+// disabled sites get another chance
+// settings that only apply on startup keep their current value
+func (sc *Scraper) Reload(cfg *Config, sites []Site) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for _, change := range sc.Cfg.KeepStartupSettings(cfg) {
+		sc.Logger.Errorf("[Scraper] Ignored change of %s, it only applies on restart", change)
+	}
+	if cfg.LogLevel != sc.Cfg.LogLevel {
+		level, err := ParseLevel(cfg.LogLevel)
 		if err != nil {
-			sc.Logger.Fatalf("[Scraper] Failed Initializing %s, %s", site.Name(), err)
+			sc.Logger.Errorf("[Scraper] Ignored log level, %s", err)
+			cfg.LogLevel = sc.Cfg.LogLevel
+		} else {
+			sc.Logger.SetLevel(level)
+			sc.Logger.Printf("[Scraper] Log level set to %s", level)
+		}
+	}
+	sc.Cfg = cfg
+
+	var wanted = make(map[string]Site)
+	for _, site := range sites {
+		wanted[site.Name()] = site
+	}
+	var current = make(map[string]Site)
+	for _, site := range sc.Sites {
+		if _, ok := wanted[site.Name()]; !ok {
+			sc.removeSite(site)
+			sc.Logger.Printf("[Scraper] Removed %s", site.Name())
+			continue
+		}
+		current[site.Name()] = site
+	}
+	for _, site := range sites {
+		existing, ok := current[site.Name()]
+		if !ok {
+			sc.addSite(site, sc.loadSchedule(site.Name()))
+			sc.Logger.Printf("[Scraper] Added %s", site.Name())
+			continue
+		}
+		state := sc.states[existing]
+		state.Schedule = sc.loadSchedule(site.Name())
+		select {
+		case state.wake <- struct{}{}:
+		default:
+		}
+		if reconfigurer, ok := existing.(Reconfigurer); ok {
+			if err := reconfigurer.Reconfigure(site); err != nil {
+				sc.Logger.Errorf("[Scraper] Failed reconfiguring %s, %s", site.Name(), err)
+			}
+		}
+		if state.Disabled {
+			state.Disabled = false
+			state.Failures = 0
+			sc.Logger.Printf("[Scraper] Enabled %s again", site.Name())
+			if sc.started && !state.active {
+				sc.launch(existing, state)
+			}
 		}
-		sc.Logger.Printf("[Scraper] Initialized %s", site.Name())
 	}
+	sc.Logger.Println("[Scraper] Config reloaded")
+}
+
+// siteList returns the current sites
+func (sc *Scraper) siteList() []Site {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return append([]Site{}, sc.Sites...)
+}
+
+// state returns the state of a site
+func (sc *Scraper) state(site Site) *siteState {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.states[site]
+}
+
+// initializeSite initializes a single site and records the result
+func (sc *Scraper) initializeSite(ctx context.Context, site Site, state *siteState) error {
+	err := site.Initialize(ctx, sc, sc.Db)
+	if err != nil {
+		sc.Logger.Errorf("[Scraper] Failed Initializing %s, %s", site.Name(), err)
+		sc.recordFailure(site, state)
+		return err
+	}
+	state.Initialized = true
+	state.Failures = 0
+	sc.Logger.Printf("[Scraper] Initialized %s", site.Name())
+	return nil
 }
 
-// Start starts scraping the different sites
-// This is blocking so call this in a goroutine
-func (sc *Scraper) Start() error {
-	sc.initialize()
+// Start starts scraping the different sites in the background
+// every site runs independently on its own schedule until Stop
+func (sc *Scraper) Start() {
+	sc.running.Add(1)
 	sc.Logger.Println("[Scraper] Scraper Started")
-	for range sc.Schedule.C {
-		for _, site := range sc.Sites {
-			sc.Logger.Printf("[Scraper] Started Scraping %s", site.Name())
-			err := site.Scrape()
-			if err != nil {
-				sc.Logger.Fatalf("[Scraper] Failed Scraping %s, %s", site.Name(), err)
-			}
+	sc.mu.Lock()
+	sc.started = true
+	for _, site := range sc.Sites {
+		sc.launch(site, sc.states[site])
+	}
+	sc.mu.Unlock()
+	go func() {
+		defer sc.running.Done()
+		// Sites can be added until the scraper stops
+		<-sc.ctx.Done()
+		sc.mu.Lock()
+		sc.started = false
+		sc.mu.Unlock()
+		sc.loops.Wait()
+	}()
+}
+
+// launch runs a site on its schedule in its own goroutine
+// it is called with mu held
+func (sc *Scraper) launch(site Site, state *siteState) {
+	state.active = true
+	sc.loops.Add(1)
+	go func() {
+		defer sc.loops.Done()
+		sc.run(sc.ctx, site, state)
+	}()
+}
+
+// Once scrapes every site a single time
+// and returns after all of them are saved
+func (sc *Scraper) Once() error {
+	var wg sync.WaitGroup
+	var sites = sc.siteList()
+	var errs = make([]error, len(sites))
+	sc.running.Add(1)
+	defer sc.running.Done()
+	for i, site := range sites {
+		wg.Add(1)
+		go func(i int, site Site) {
+			defer wg.Done()
+			errs[i] = sc.scrapeSite(sc.ctx, site, sc.state(site))
+		}(i, site)
+	}
+	wg.Wait()
+	return joinErrors(errs)
+}
+
+// Backfill stores the past seasons of every site
+// an empty regions list backfills the regions of each site
+func (sc *Scraper) Backfill(regions []string) error {
+	var sites = sc.siteList()
+	var errs = make([]error, len(sites))
+	sc.running.Add(1)
+	defer sc.running.Done()
+	for i, site := range sites {
+		backfiller, ok := site.(Backfiller)
+		if !ok {
+			sc.Logger.Printf("[Scraper] %s does not support backfill", site.Name())
+			continue
+		}
+		if err := sc.initializeSite(sc.ctx, site, sc.state(site)); err != nil {
+			errs[i] = fmt.Errorf("%s: %w", site.Name(), err)
+			continue
+		}
+		sc.Logger.Printf("[Scraper] Started Backfilling %s", site.Name())
+		if err := backfiller.Backfill(sc.ctx, regions); err != nil {
+			errs[i] = fmt.Errorf("%s: %w", site.Name(), err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// Replay rebuilds the points of every site from the archive
+// the database is expected to be empty
+func (sc *Scraper) Replay() error {
+	var sites = sc.siteList()
+	var errs = make([]error, len(sites))
+	sc.running.Add(1)
+	defer sc.running.Done()
+	if sc.Archive == nil {
+		return fmt.Errorf("no archive to replay")
+	}
+	for i, site := range sites {
+		replayer, ok := site.(Replayer)
+		if !ok {
+			sc.Logger.Printf("[Scraper] %s does not support replay", site.Name())
+			continue
+		}
+		sc.Logger.Printf("[Scraper] Started Replaying %s", site.Name())
+		if err := replayer.Replay(sc.ctx, sc, sc.Db); err != nil {
+			errs[i] = fmt.Errorf("%s: %w", site.Name(), err)
+		}
+	}
+	return joinErrors(errs)
+}
+
+// run scrapes a site right away and then on its schedule
+// until the context is cancelled, the site is removed or disabled
+func (sc *Scraper) run(ctx context.Context, site Site, state *siteState) {
+	for {
+		sc.scrapeSite(ctx, site, state)
+		// A reload can enable the site again until it is marked inactive
+		sc.mu.Lock()
+		disabled := state.Disabled
+		if disabled {
+			state.active = false
+		}
+		sc.mu.Unlock()
+		if disabled || !sc.wait(ctx, state, time.Now()) {
+			return
+		}
+	}
+}
+
+// wait blocks until the next scrape of a site is due
+// it returns false once the site should stop
+func (sc *Scraper) wait(ctx context.Context, state *siteState, last time.Time) bool {
+	for {
+		sc.mu.Lock()
+		next := state.Schedule.Next(last)
+		sc.mu.Unlock()
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-state.stop:
+			timer.Stop()
+			return false
+		case <-state.wake:
+			// The schedule changed, the next scrape is counted again from the last one
+			timer.Stop()
+		case <-timer.C:
+			return true
 		}
 	}
+}
+
+// scrapeSite scrapes a single site, initializing it first
+// if it was not initialized yet
+func (sc *Scraper) scrapeSite(ctx context.Context, site Site, state *siteState) error {
+	if state.Disabled {
+		return fmt.Errorf("%s is disabled", site.Name())
+	}
+	if !state.Initialized {
+		if err := sc.initializeSite(ctx, site, state); err != nil {
+			return fmt.Errorf("%s: %w", site.Name(), err)
+		}
+	}
+	sc.Logger.Printf("[Scraper] Started Scraping %s", site.Name())
+	err := site.Scrape(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		sc.Logger.Errorf("[Scraper] Failed Scraping %s, %s", site.Name(), err)
+		sc.recordFailure(site, state)
+		return fmt.Errorf("%s: %w", site.Name(), err)
+	}
+	state.Failures = 0
 	return nil
 }
 
-// Stop stops the scheduler
-// active tasks may take some time stopping
+// recordFailure counts a consecutive failure of a site
+// and disables it once it reaches the configured limit
+func (sc *Scraper) recordFailure(site Site, state *siteState) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	state.Failures++
+	if sc.Cfg.MaxFailures > 0 && state.Failures >= sc.Cfg.MaxFailures {
+		state.Disabled = true
+		sc.Logger.Errorf("[Scraper] Disabled %s after %d consecutive failures", site.Name(), state.Failures)
+	}
+}
+
+// archive saves the raw body of a page if archiving is enabled
+// failures are only logged, the scrape itself is still good
+func (sc *Scraper) archive(mode, region string, season, page int, t time.Time, body []byte) {
+	if sc.Archive == nil || t.IsZero() {
+		return
+	}
+	if err := sc.Archive.Save(mode, region, season, page, t.Unix(), body); err != nil {
+		sc.Logger.Errorf("[Scraper] Failed archiving %s region %s page %d, %s", mode, region, page, err)
+	}
+}
+
+// acquire blocks until a worker from the pool is free
+func (sc *Scraper) acquire() {
+	sc.Pool <- struct{}{}
+}
+
+// release frees a worker acquired from the pool
+func (sc *Scraper) release() {
+	<-sc.Pool
+}
+
+// Stop stops the scheduler, cancels in-flight requests
+// and waits for the current scrapes to finish writing
 func (sc *Scraper) Stop() {
 	sc.Logger.Println("[Scraper] Scraper Stopping...")
-	sc.Schedule.Stop()
+	sc.cancel()
+	sc.running.Wait()
+	sc.Logger.Println("[Scraper] Scraper Stopped")
+}
+
+// joinErrors combines the non nil errors into a single error
+// a single error is returned as is so it can still be inspected
+func joinErrors(errs []error) error {
+	var msgs = make([]string, 0)
+	var last error
+	for _, err := range errs {
+		if err != nil {
+			msgs = append(msgs, err.Error())
+			last = err
+		}
+	}
+	switch len(msgs) {
+	case 0:
+		return nil
+	case 1:
+		return last
+	}
+	return fmt.Errorf("%s", strings.Join(msgs, "; "))
 }
@@ -0,0 +1,511 @@
+package hsleaderboards_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	hs "hsleaderboards"
+	"hsleaderboards/hstest"
+)
+
+// setup starts a fake server and a fresh database
+func setup(t *testing.T) (*hstest.Server, *hs.SQLStorage, *hs.Scraper) {
+	t.Helper()
+	srv := hstest.NewServer()
+	t.Cleanup(srv.Close)
+	logger := log.New(ioutil.Discard, "", 0)
+	cfg := &hs.Config{Interval: 600, Concurrency: 2}
+	db := openStorage(t, logger, cfg)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	return srv, db, hs.MakeScraper(db, logger, cfg)
+}
+
+// openStorage opens an empty database for a test
+// TestPostgres swaps it to run the tests against Postgres
+var openStorage = openSQLite
+
+func openSQLite(t *testing.T, logger *log.Logger, cfg *hs.Config) *hs.SQLStorage {
+	t.Helper()
+	cfg.DBPath = filepath.Join(t.TempDir(), "test.db")
+	db, err := hs.MakeSQLite(logger, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// latest returns the latest point of every player in a region
+func latest(t *testing.T, db hs.Storage, table string, season int) map[string]hs.Point {
+	t.Helper()
+	curr, _, err := db.LatestSnapshots(table, season, "US")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var points = make(map[string]hs.Point)
+	if curr == nil {
+		return points
+	}
+	for _, p := range curr.Points {
+		points[p.Name] = p
+	}
+	return points
+}
+
+// count returns the number of stored points of a table
+func count(t *testing.T, db *hs.SQLStorage, table string) int {
+	t.Helper()
+	var n int
+	if err := db.Session.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestInitializeUsesLatestSeason(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 103, 105, 104)
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if site.LatestSeason != 105 {
+		t.Fatalf("LatestSeason = %d, want 105", site.LatestSeason)
+	}
+}
+
+func TestScrapeStoresEveryPage(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105,
+		hstest.Row{Name: "a", Rank: 1},
+		hstest.Row{Name: "b", Rank: 2},
+		hstest.Row{Name: "c", Rank: 3},
+		hstest.Row{Name: "d", Rank: 4},
+		hstest.Row{Name: "e", Rank: 5},
+	)
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	points := latest(t, db, "standard", 105)
+	if len(points) != 5 {
+		t.Fatalf("got %d players, want 5", len(points))
+	}
+	if points["e"].Rank != 5 {
+		t.Fatalf("rank of e = %d, want 5", points["e"].Rank)
+	}
+}
+
+func TestScrapeUpdatesStablePoints(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1}, hstest.Row{Name: "b", Rank: 2})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := site.Scrape(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Stable players keep extending their point
+	if n := count(t, db, "standard"); n != 2 {
+		t.Fatalf("got %d points, want 2", n)
+	}
+	srv.Set("STD", "US", 105, hstest.Row{Name: "b", Rank: 1}, hstest.Row{Name: "a", Rank: 2})
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, "standard"); n != 4 {
+		t.Fatalf("got %d points, want 4", n)
+	}
+	if points := latest(t, db, "standard", 105); points["a"].Rank != 2 {
+		t.Fatalf("rank of a = %d, want 2", points["a"].Rank)
+	}
+}
+
+func TestRestartContinuesPoints(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1}, hstest.Row{Name: "b", Rank: 2})
+	for restart := 0; restart < 2; restart++ {
+		site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+		site.Regions = []string{"US"}
+		if err := site.Initialize(context.Background(), sc, db); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := site.Scrape(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// The unchanged leaderboard extends the points stored before the restart
+		if n := count(t, db, "standard"); n != 2 {
+			t.Fatalf("restart %d: got %d points, want 2", restart, n)
+		}
+	}
+}
+
+func TestScrapeKeepsDuplicateNamesApart(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("BG", 6)
+	srv.Set("BG", "US", 6, hstest.Row{Name: "a", Rank: 1, Rating: 9000}, hstest.Row{Name: "a", Rank: 2, Rating: 8000})
+	site := hs.MakeLeaderboard(hs.Battlegrounds, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// The second player climbs past the first one
+	srv.Set("BG", "US", 6, hstest.Row{Name: "a", Rank: 1, Rating: 8010}, hstest.Row{Name: "a", Rank: 2, Rating: 8990})
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	points := latest(t, db, "battlegrounds", 6)
+	if p := points["a"]; p.Rank != 2 || p.Rating != 8990 {
+		t.Fatalf("a = rank %d rating %d, want rank 2 rating 8990", p.Rank, p.Rating)
+	}
+	if p := points["a|2"]; p.Rank != 1 || p.Rating != 8010 {
+		t.Fatalf("a|2 = rank %d rating %d, want rank 1 rating 8010", p.Rank, p.Rating)
+	}
+}
+
+func TestScrapeNeverReusesStoredDuplicates(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("BG", 6)
+	site := hs.MakeLeaderboard(hs.Battlegrounds, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	// The second player leaves and a different one shows up later
+	for _, rows := range [][]hstest.Row{
+		{{Name: "a", Rank: 1, Rating: 9000}, {Name: "a", Rank: 2, Rating: 8000}},
+		{{Name: "a", Rank: 1, Rating: 9000}},
+		{{Name: "a", Rank: 1, Rating: 9000}, {Name: "a", Rank: 5, Rating: 100}},
+	} {
+		srv.Set("BG", "US", 6, rows...)
+		if err := site.Scrape(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	points := latest(t, db, "battlegrounds", 6)
+	if p := points["a|2"]; p.Rating != 8000 {
+		t.Fatalf("a|2 = rating %d, want 8000", p.Rating)
+	}
+	if p, ok := points["a|3"]; !ok || p.Rating != 100 {
+		t.Fatalf("a|3 = rating %d, want 100", p.Rating)
+	}
+}
+
+func TestScrapeRollsOverToNextSeason(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	srv.Seasons("STD", 105, 106)
+	srv.Set("STD", "US", 106, hstest.Row{Name: "b", Rank: 1})
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if site.LatestSeason != 106 {
+		t.Fatalf("LatestSeason = %d, want 106", site.LatestSeason)
+	}
+	for season, name := range map[int]string{105: "a", 106: "b"} {
+		if _, ok := latest(t, db, "standard", season)[name]; !ok {
+			t.Fatalf("%s missing from season %d", name, season)
+		}
+	}
+}
+
+func TestScrapeNeverRollsBackToOlderSeason(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 104, 105)
+	srv.RegionSeasons("STD", "EU", 104)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	srv.Set("STD", "EU", 105, hstest.Row{Name: "b", Rank: 1})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US", "EU"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := site.Scrape(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if site.LatestSeason != 105 || site.NextSeason != 0 {
+		t.Fatalf("LatestSeason = %d, NextSeason = %d, want 105 and 0", site.LatestSeason, site.NextSeason)
+	}
+	if n := count(t, db, "seasons"); n != 0 {
+		t.Fatalf("got %d season changes, want 0", n)
+	}
+	if n := count(t, db, "standard"); n != 2 {
+		t.Fatalf("got %d points, want 2", n)
+	}
+}
+
+// fastRetry retries without waiting long
+var fastRetry = hs.RetryPolicy{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+func TestScrapeReportsErrors(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	site.Retry = fastRetry
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("STD", 10, http.StatusInternalServerError, "internal error")
+	before := srv.Requests()
+	err := site.Scrape(context.Background())
+	var status *hs.StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusInternalServerError {
+		t.Fatalf("got error %v, want status 500", err)
+	}
+	if n := srv.Requests() - before; n != 3 {
+		t.Fatalf("got %d requests, want 3", n)
+	}
+}
+
+func TestScrapeRetriesServerErrors(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	site.Retry = fastRetry
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("STD", 1, http.StatusBadGateway, "")
+	srv.Throttle("STD", 1, "0")
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := latest(t, db, "standard", 105)["a"]; !ok {
+		t.Fatal("a missing after retries")
+	}
+}
+
+func TestScrapeDoesNotRetryClientErrors(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	site.Retry = fastRetry
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail("STD", 1, http.StatusNotFound, "not found")
+	before := srv.Requests()
+	if err := site.Scrape(context.Background()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if n := srv.Requests() - before; n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
+
+// waitFor polls until cond holds or fails the test after a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestScraperWithoutConcurrencyScrapes(t *testing.T) {
+	srv, db, _ := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	sc := hs.MakeScraper(db, log.New(ioutil.Discard, "", 0), &hs.Config{Interval: 600})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	sc.AddSite(site)
+	var done = make(chan error, 1)
+	go func() { done <- sc.Once() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scrape blocked without concurrency")
+	}
+	if n := count(t, db, "standard"); n != 1 {
+		t.Fatalf("got %d points, want 1", n)
+	}
+}
+
+func TestReloadAddsAndRemovesSites(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Seasons("WLD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	srv.Set("WLD", "US", 105, hstest.Row{Name: "b", Rank: 1})
+	site := func(mode hs.Mode) hs.Site {
+		b := hs.MakeLeaderboard(mode, srv.Fetcher())
+		b.Regions = []string{"US"}
+		return b
+	}
+	sc.AddSite(site(hs.Standard))
+	sc.Start()
+	defer sc.Stop()
+	waitFor(t, "standard points", func() bool { return count(t, db, "standard") > 0 })
+
+	next := *sc.Cfg
+	next.DBPath = "other.db"
+	next.Interval = 60
+	sc.Reload(&next, []hs.Site{site(hs.Wild)})
+	waitFor(t, "wild points", func() bool { return count(t, db, "wild") > 0 })
+
+	if len(sc.Sites) != 1 || sc.Sites[0].Name() != "Wild" {
+		t.Fatalf("sites after reload = %v, want only Wild", sc.Sites)
+	}
+	if sc.Cfg.DBPath == "other.db" {
+		t.Fatal("db path changed on reload")
+	}
+	if sc.Cfg.Interval != 60 {
+		t.Fatalf("interval = %d, want 60", sc.Cfg.Interval)
+	}
+}
+
+// syncBuffer collects log output written by several goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Contains(s string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Contains(b.buf.String(), s)
+}
+
+func TestReloadEnablesDisabledSites(t *testing.T) {
+	srv, db, sc := setup(t)
+	var out syncBuffer
+	sc.Logger = hs.MakeLogger(log.New(&out, "", 0), hs.LevelInfo)
+	sc.Cfg.MaxFailures = 1
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	srv.Fail("STD", 1, http.StatusInternalServerError, "internal error")
+	site := func() hs.Site {
+		b := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+		b.Regions = []string{"US"}
+		b.Retry = hs.RetryPolicy{Attempts: 1}
+		return b
+	}
+	sc.AddSite(site())
+	sc.Start()
+	defer sc.Stop()
+	waitFor(t, "standard disabled", func() bool { return out.Contains("Disabled Standard") })
+
+	next := *sc.Cfg
+	sc.Reload(&next, []hs.Site{site()})
+	waitFor(t, "standard points", func() bool { return count(t, db, "standard") > 0 })
+}
+
+func TestReconfigureAddsRegions(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 105)
+	srv.Set("STD", "US", 105, hstest.Row{Name: "a", Rank: 1})
+	srv.Set("STD", "EU", 105, hstest.Row{Name: "b", Rank: 1})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	next := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	next.Regions = []string{"EU"}
+	if err := site.Reconfigure(next); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Scrape(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(t, db, "standard"); n != 2 {
+		t.Fatalf("got %d points, want 2", n)
+	}
+	curr, _, err := db.LatestSnapshots("standard", 105, "EU")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if curr == nil || len(curr.Points) != 1 || curr.Points[0].Name != "b" {
+		t.Fatalf("EU snapshot = %v, want b", curr)
+	}
+	if err := site.Reconfigure(hs.MakeLeaderboard(hs.Wild, srv.Fetcher())); err == nil {
+		t.Fatal("reconfigured Standard from Wild")
+	}
+}
+
+func TestBackfillDatesSeasonsByTheirEnd(t *testing.T) {
+	srv, db, sc := setup(t)
+	srv.Seasons("STD", 103, 104, 105)
+	srv.Set("STD", "US", 103, hstest.Row{Name: "a", Rank: 1})
+	srv.Set("STD", "US", 104, hstest.Row{Name: "b", Rank: 1})
+	site := hs.MakeLeaderboard(hs.Standard, srv.Fetcher())
+	site.Regions = []string{"US"}
+	if err := site.Initialize(context.Background(), sc, db); err != nil {
+		t.Fatal(err)
+	}
+	if err := site.Backfill(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, season := range []int{103, 104} {
+		var points []hs.Point
+		err := db.Points("standard", hs.PointFilter{Season: season}, func(p *hs.Point) error {
+			points = append(points, *p)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		end := hstest.SeasonStart(season + 1).Unix()
+		if len(points) != 1 || points[0].FirstSeen != end || points[0].Timestamp != end {
+			t.Fatalf("season %d points = %+v, want one dated %d", season, points, end)
+		}
+	}
+	if n := count(t, db, "standard"); n != 2 {
+		t.Fatalf("got %d points, want 2, the running season is not backfilled", n)
+	}
+}
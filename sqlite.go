@@ -0,0 +1,76 @@
+package hsleaderboards
+
+import (
+	"database/sql"
+	"embed"
+	"log"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+//go:embed migrations/sqlite/*.sql
+var sqlite_migrations embed.FS
+
+//go:embed queries/lb_new.sql
+var leaderboard_new string
+
+//go:embed queries/lb_update.sql
+var leaderboard_update string
+
+//go:embed queries/lb_latest.sql
+var leaderboard_latest string
+
+//go:embed queries/lb_exists.sql
+var leaderboard_exists string
+
+//go:embed queries/lb_points.sql
+var leaderboard_points string
+
+//go:embed queries/lb_stats.sql
+var leaderboard_stats string
+
+//go:embed queries/se_new.sql
+var seasons_new string
+
+//go:embed queries/pl_new.sql
+var player_new string
+
+//go:embed queries/sv_create.sql
+var version_create string
+
+//go:embed queries/sv_new.sql
+var version_new string
+
+//go:embed queries/sv_current.sql
+var version_current string
+
+//go:embed queries/sv_exists.sql
+var version_exists string
+
+var sqliteDialect = &sqlDialect{
+	leaderboards: sqlQueries{
+		new:    leaderboard_new,
+		update: leaderboard_update,
+		latest: leaderboard_latest,
+		exists: leaderboard_exists,
+		points: leaderboard_points,
+		stats:  leaderboard_stats,
+	},
+	seasonsNew:     seasons_new,
+	playerNew:      player_new,
+	versionCreate:  version_create,
+	versionNew:     version_new,
+	versionCurrent: version_current,
+	versionExists:  version_exists,
+	migrations:     mustSub(sqlite_migrations, "migrations/sqlite"),
+}
+
+func MakeSQLite(logger *log.Logger, cfg *Config) (*SQLStorage, error) {
+	db, err := sql.Open("sqlite3", cfg.DBPath)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so scrapers share one connection
+	db.SetMaxOpenConns(1)
+	return makeSQLStorage(logger, cfg, db, sqliteDialect), nil
+}
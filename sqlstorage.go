@@ -0,0 +1,384 @@
+package hsleaderboards
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// sqlTable holds the queries of a game mode table
+type sqlTable struct {
+	new    string
+	update string
+	latest string
+	exists string
+	points string
+	stats  string
+	rating bool
+	score  bool
+}
+
+// sqlQueries holds the query templates of the game mode tables
+// they are rendered with the Mode of every table
+type sqlQueries struct {
+	new    string
+	update string
+	latest string
+	exists string
+	points string
+	stats  string
+}
+
+// sqlDialect holds the queries and migrations of a database backend
+type sqlDialect struct {
+	leaderboards   sqlQueries
+	seasonsNew     string
+	playerNew      string
+	versionCreate  string
+	versionNew     string
+	versionCurrent string
+	versionExists  string
+	migrations     fs.FS
+}
+
+// SQLStorage implements Storage on top of database/sql
+// every backend brings its own dialect
+type SQLStorage struct {
+	Cfg     *Config
+	Session *sql.DB
+	Logger  *log.Logger
+	dialect *sqlDialect
+	tables  map[string]*sqlTable
+}
+
+func makeSQLStorage(logger *log.Logger, cfg *Config, db *sql.DB, dialect *sqlDialect) *SQLStorage {
+	return &SQLStorage{
+		Cfg:     cfg,
+		Session: db,
+		Logger:  logger,
+		dialect: dialect,
+		tables:  makeTables(&dialect.leaderboards, Modes),
+	}
+}
+
+// makeTables renders the queries of every game mode table
+func makeTables(queries *sqlQueries, modes []Mode) map[string]*sqlTable {
+	var tables = make(map[string]*sqlTable)
+	for _, mode := range modes {
+		tables[mode.Table] = &sqlTable{
+			new:    renderQuery(queries.new, mode),
+			update: renderQuery(queries.update, mode),
+			latest: renderQuery(queries.latest, mode),
+			exists: renderQuery(queries.exists, mode),
+			points: renderQuery(queries.points, mode),
+			stats:  renderQuery(queries.stats, mode),
+			rating: mode.Rated,
+			score:  mode.Scored,
+		}
+	}
+	return tables
+}
+
+// renderQuery fills a query template with the table of a mode
+// the templates are embedded, so a broken one is a bug
+func renderQuery(query string, mode Mode) string {
+	var buf strings.Builder
+	err := template.Must(template.New(mode.Table).Parse(query)).Execute(&buf, mode)
+	if err != nil {
+		panic(err)
+	}
+	return buf.String()
+}
+
+// table gets the queries of a game mode table
+func (d *SQLStorage) table(name string) (*sqlTable, error) {
+	t, ok := d.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown table %s", name)
+	}
+	return t, nil
+}
+
+// AddPlayers upserts the players in name order in their own short
+// transaction, so scrapes of modes sharing names can not deadlock
+func (d *SQLStorage) AddPlayers(region string, names []string) error {
+	if len(names) == 0 {
+		return nil
+	}
+	var sorted = append([]string{}, names...)
+	sort.Strings(sorted)
+	tx, err := d.Session.Begin()
+	if err != nil {
+		return err
+	}
+	stmt, err := tx.Prepare(d.dialect.playerNew)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, name := range sorted {
+		if _, err = stmt.Exec(region, name); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (d *SQLStorage) Begin(table string) (Batch, error) {
+	t, err := d.table(table)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := d.Session.Begin()
+	if err != nil {
+		return nil, err
+	}
+	newStmt, err := tx.Prepare(t.new)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	updateStmt, err := tx.Prepare(t.update)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &sqlBatch{
+		tx:     tx,
+		table:  t,
+		new:    newStmt,
+		update: updateStmt,
+	}, nil
+}
+
+func (d *SQLStorage) LatestSnapshots(table string, season int, region string) (curr, prev *Snapshot, err error) {
+	var latest = make(map[string]Point)
+	var previous = make(map[string]Point)
+	t, err := d.table(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := d.Session.Query(t.latest, season, region)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p = Point{Season: season, Region: region}
+		var position int
+		if t.rating {
+			err = rows.Scan(&p.Timestamp, &p.Name, &p.Rank, &p.Rating, &position)
+		} else if t.score {
+			err = rows.Scan(&p.Timestamp, &p.Name, &p.Rank, &p.Score, &position)
+		} else {
+			err = rows.Scan(&p.Timestamp, &p.Name, &p.Rank, &position)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if position == 1 {
+			latest[p.Name] = p
+		} else {
+			previous[p.Name] = p
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	curr, prev = buildSnapshots(latest, previous)
+	return curr, prev, nil
+}
+
+func (d *SQLStorage) HasSeason(table string, season int, region string) (bool, error) {
+	var exists bool
+	t, err := d.table(table)
+	if err != nil {
+		return false, err
+	}
+	err = d.Session.QueryRow(t.exists, season, region).Scan(&exists)
+	return exists, err
+}
+
+func (d *SQLStorage) Points(table string, filter PointFilter, fn func(*Point) error) error {
+	t, err := d.table(table)
+	if err != nil {
+		return err
+	}
+	rows, err := d.Session.Query(t.points, filter.Season, filter.Region, filter.Name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p Point
+		if t.rating {
+			err = rows.Scan(&p.FirstSeen, &p.Timestamp, &p.Season, &p.Region, &p.Name, &p.Rank, &p.Rating, &p.Confidence)
+		} else if t.score {
+			err = rows.Scan(&p.FirstSeen, &p.Timestamp, &p.Season, &p.Region, &p.Name, &p.Rank, &p.Score, &p.Confidence)
+		} else {
+			err = rows.Scan(&p.FirstSeen, &p.Timestamp, &p.Season, &p.Region, &p.Name, &p.Rank, &p.Confidence)
+		}
+		if err != nil {
+			return err
+		}
+		if err = fn(&p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (d *SQLStorage) Stats(table string) ([]SeasonStats, error) {
+	var stats = make([]SeasonStats, 0)
+	t, err := d.table(table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.Session.Query(t.stats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s SeasonStats
+		err = rows.Scan(&s.Season, &s.Region, &s.Points, &s.Players, &s.FirstSeen, &s.LastSeen)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+func (d *SQLStorage) InsertSnapshot(table string, s *Snapshot) error {
+	var names = make([]string, 0, len(s.Points))
+	for _, p := range s.Points {
+		names = append(names, p.Name)
+	}
+	if err := d.AddPlayers(s.Region, names); err != nil {
+		return err
+	}
+	batch, err := d.Begin(table)
+	if err != nil {
+		return err
+	}
+	for i := range s.Points {
+		if err = batch.NewPoint(&s.Points[i]); err != nil {
+			batch.Rollback()
+			return err
+		}
+	}
+	return batch.Commit()
+}
+
+func (d *SQLStorage) SeasonChange(mode string, ended, started int, t int64) error {
+	_, err := d.Session.Exec(d.dialect.seasonsNew, t, mode, ended, started)
+	return err
+}
+
+func (d *SQLStorage) SchemaVersion() (int, error) {
+	var version, exists int
+	err := d.Session.QueryRow(d.dialect.versionExists).Scan(&exists)
+	if err != nil || exists == 0 {
+		return 0, err
+	}
+	err = d.Session.QueryRow(d.dialect.versionCurrent).Scan(&version)
+	return version, err
+}
+
+func (d *SQLStorage) Migrate(dryRun bool) ([]Migration, error) {
+	migrations, err := loadMigrations(d.dialect.migrations)
+	if err != nil {
+		return nil, err
+	}
+	version, err := d.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	pending := pendingMigrations(migrations, version)
+	if dryRun {
+		return pending, nil
+	}
+	if _, err = d.Session.Exec(d.dialect.versionCreate); err != nil {
+		return nil, err
+	}
+	for i, m := range pending {
+		if err = d.applyMigration(m); err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s: %w", m.Version, m.Name, err)
+		}
+		d.Logger.Printf("[Storage] Applied migration %04d_%s", m.Version, m.Name)
+	}
+	return pending, nil
+}
+
+// applyMigration runs a migration and records it in one transaction
+func (d *SQLStorage) applyMigration(m Migration) error {
+	tx, err := d.Session.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(m.Query); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.Exec(d.dialect.versionNew, m.Version, m.Name, time.Now().Unix()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *SQLStorage) Close() error {
+	return d.Session.Close()
+}
+
+// newArgs gets the arguments of the new point query
+// a score takes the place of the rating
+func (t *sqlTable) newArgs(p *Point) []interface{} {
+	if t.rating {
+		return []interface{}{p.FirstSeen, p.Timestamp, p.Season, p.Region, p.Name, p.Rank, p.Rating, p.Confidence}
+	}
+	if t.score {
+		return []interface{}{p.FirstSeen, p.Timestamp, p.Season, p.Region, p.Name, p.Rank, p.Score, p.Confidence}
+	}
+	return []interface{}{p.FirstSeen, p.Timestamp, p.Season, p.Region, p.Name, p.Rank, p.Confidence}
+}
+
+// sqlBatch is a transaction with the point queries prepared
+type sqlBatch struct {
+	tx     *sql.Tx
+	table  *sqlTable
+	new    *sql.Stmt
+	update *sql.Stmt
+}
+
+// NewPoint inserts a new point, the player has to be stored already
+func (b *sqlBatch) NewPoint(p *Point) error {
+	res, err := b.new.Exec(b.table.newArgs(p)...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("player %s in region %s is not stored", p.Name, p.Region)
+	}
+	return nil
+}
+
+func (b *sqlBatch) UpdatePoint(p *Point) error {
+	_, err := b.update.Exec(p.Timestamp, p.Season, p.Region, p.Name)
+	return err
+}
+
+func (b *sqlBatch) Commit() error {
+	return b.tx.Commit()
+}
+
+func (b *sqlBatch) Rollback() error {
+	return b.tx.Rollback()
+}
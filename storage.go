@@ -0,0 +1,139 @@
+package hsleaderboards
+
+import (
+	"fmt"
+	"log"
+)
+
+// Storage is the interface every database backend implements
+// game modes are stored in separate tables, identified by name
+type Storage interface {
+	// Migrate applies the pending schema migrations and returns them
+	// on a dry run the pending migrations are only returned
+	// and the database is not written to
+	Migrate(dryRun bool) ([]Migration, error)
+	// SchemaVersion returns the version of the last applied migration
+	// 0 if none was applied, it never writes to the database
+	SchemaVersion() (int, error)
+	// AddPlayers adds the players of a region that are not stored yet
+	// points can only be inserted for stored players
+	AddPlayers(region string, names []string) error
+	// Begin starts a batch of writes to a game mode table
+	Begin(table string) (Batch, error)
+	// LatestSnapshots rebuilds the last two snapshots of a region
+	// both are nil if nothing is stored yet
+	LatestSnapshots(table string, season int, region string) (curr, prev *Snapshot, err error)
+	// HasSeason checks if any point of a season is stored
+	HasSeason(table string, season int, region string) (bool, error)
+	// Points calls fn with the stored points of a table in insertion order
+	// zero fields of the filter match every point
+	Points(table string, filter PointFilter, fn func(*Point) error) error
+	// Stats summarizes the stored points of a table by season and region
+	Stats(table string) ([]SeasonStats, error)
+	// InsertSnapshot inserts every point of a snapshot at once
+	InsertSnapshot(table string, s *Snapshot) error
+	// SeasonChange records the boundary between two seasons of a game mode
+	SeasonChange(mode string, ended, started int, t int64) error
+	Close() error
+}
+
+// Batch is a group of writes that is saved or discarded as a whole
+type Batch interface {
+	// NewPoint inserts a new point for a stored player
+	NewPoint(p *Point) error
+	// UpdatePoint extends the latest point of a player to p.Timestamp
+	UpdatePoint(p *Point) error
+	Commit() error
+	Rollback() error
+}
+
+// Point is a single leaderboard entry of a player
+// Timestamp is when the rank was last seen
+// Rating is ignored by game modes without rating, Score by those without score
+// Confidence is how certain the match of a duplicate name to its history is
+type Point struct {
+	FirstSeen  int64
+	Timestamp  int64
+	Season     int
+	Region     string
+	Name       string
+	Rank       int
+	Rating     int
+	Score      float64
+	Confidence float64
+}
+
+// PointFilter selects the points of a season, region or player
+// a name also matches its duplicates, like name|2
+type PointFilter struct {
+	Season int
+	Region string
+	Name   string
+}
+
+// SeasonStats summarizes the points of a season in a region
+type SeasonStats struct {
+	Season    int
+	Region    string
+	Points    int
+	Players   int
+	FirstSeen int64
+	LastSeen  int64
+}
+
+// Snapshot is a whole leaderboard of a region at a point in time
+type Snapshot struct {
+	Timestamp int64
+	Season    int
+	Region    string
+	Points    []Point
+}
+
+// MakeStorage opens the storage backend selected by DB_DRIVER
+func MakeStorage(logger *log.Logger, cfg *Config) (Storage, error) {
+	switch cfg.DBDriver {
+	case "sqlite3", "sqlite":
+		return MakeSQLite(logger, cfg)
+	case "postgres":
+		if cfg.DBURL == "" {
+			return nil, fmt.Errorf("DB_URL is required for the postgres driver")
+		}
+		return MakePostgres(logger, cfg)
+	default:
+		return nil, fmt.Errorf("unknown DB_DRIVER %q", cfg.DBDriver)
+	}
+}
+
+// buildSnapshots rebuilds the last two snapshots of a region
+// from the latest and one before latest points of every player
+func buildSnapshots(latest, previous map[string]Point) (curr, prev *Snapshot) {
+	var timestamp int64
+	var season int
+	var region string
+	for _, p := range latest {
+		if p.Timestamp > timestamp {
+			timestamp = p.Timestamp
+			season = p.Season
+			region = p.Region
+		}
+	}
+	if len(latest) == 0 {
+		return nil, nil
+	}
+	curr = &Snapshot{Timestamp: timestamp, Season: season, Region: region}
+	prev = &Snapshot{Timestamp: timestamp, Season: season, Region: region}
+	for name, p := range latest {
+		// Only players seen in the latest scrape are in the snapshots
+		if p.Timestamp != timestamp {
+			continue
+		}
+		curr.Points = append(curr.Points, p)
+		// Players with a single point were stable in the scrape before
+		if before, ok := previous[name]; ok {
+			prev.Points = append(prev.Points, before)
+		} else {
+			prev.Points = append(prev.Points, p)
+		}
+	}
+	return curr, prev
+}
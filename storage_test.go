@@ -0,0 +1,208 @@
+package hsleaderboards_test
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	hs "hsleaderboards"
+)
+
+// insert stores a snapshot of points in the standard table
+func insert(t *testing.T, db hs.Storage, timestamp int64, season int, region string, names ...string) {
+	t.Helper()
+	var s = &hs.Snapshot{Timestamp: timestamp, Season: season, Region: region}
+	for i, name := range names {
+		s.Points = append(s.Points, hs.Point{FirstSeen: timestamp, Timestamp: timestamp, Season: season, Region: region, Name: name, Rank: i + 1, Confidence: 1})
+	}
+	if err := db.InsertSnapshot("standard", s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// points returns the names of the points matching a filter
+func points(t *testing.T, db hs.Storage, filter hs.PointFilter) []string {
+	t.Helper()
+	var names = make([]string, 0)
+	err := db.Points("standard", filter, func(p *hs.Point) error {
+		names = append(names, p.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return names
+}
+
+func TestPointsFilters(t *testing.T) {
+	_, db, _ := setup(t)
+	insert(t, db, 100, 104, "US", "a", "b")
+	insert(t, db, 200, 105, "US", "a", "a|2", "ab")
+	insert(t, db, 200, 105, "EU", "a")
+	insert(t, db, 300, 106, "US", "Bob", "Bob|2", "BobX")
+
+	cases := []struct {
+		filter hs.PointFilter
+		want   int
+	}{
+		{hs.PointFilter{}, 9},
+		{hs.PointFilter{Season: 105}, 4},
+		{hs.PointFilter{Region: "EU"}, 1},
+		{hs.PointFilter{Season: 105, Region: "US", Name: "a"}, 2},
+		{hs.PointFilter{Name: "b"}, 1},
+		{hs.PointFilter{Name: "Bob"}, 2},
+		{hs.PointFilter{Name: "bob"}, 0},
+	}
+	for _, c := range cases {
+		if got := points(t, db, c.filter); len(got) != c.want {
+			t.Errorf("%+v: got %v, want %d points", c.filter, got, c.want)
+		}
+	}
+}
+
+func TestStatsBySeasonAndRegion(t *testing.T) {
+	_, db, _ := setup(t)
+	insert(t, db, 100, 105, "US", "a", "b")
+	insert(t, db, 200, 105, "US", "a")
+	insert(t, db, 200, 105, "EU", "a")
+
+	stats, err := db.Stats("standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []hs.SeasonStats{
+		{Season: 105, Region: "EU", Points: 1, Players: 1, FirstSeen: 200, LastSeen: 200},
+		{Season: 105, Region: "US", Points: 3, Players: 2, FirstSeen: 100, LastSeen: 200},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %+v, want %+v", stats, want)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("got %+v, want %+v", stats[i], want[i])
+		}
+	}
+}
+
+func TestNewPointNeedsStoredPlayer(t *testing.T) {
+	_, db, _ := setup(t)
+	var p = hs.Point{FirstSeen: 100, Timestamp: 100, Season: 105, Region: "US", Name: "a", Rank: 1, Confidence: 1}
+	batch, err := db.Begin("standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.NewPoint(&p); err == nil {
+		t.Fatal("stored a point of a player that is not stored")
+	}
+	batch.Rollback()
+
+	if err := db.AddPlayers("US", []string{"b", "a", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	batch, err = db.Begin("standard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.NewPoint(&p); err != nil {
+		t.Fatal(err)
+	}
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if got := points(t, db, hs.PointFilter{}); len(got) != 1 {
+		t.Fatalf("got %v, want one point", got)
+	}
+}
+
+func TestSchemaVersionDoesNotWrite(t *testing.T) {
+	db := openStorage(t, log.New(ioutil.Discard, "", 0), &hs.Config{})
+	t.Cleanup(func() { db.Close() })
+	version, err := db.SchemaVersion()
+	if err != nil || version != 0 {
+		t.Fatalf("got version %d, %v, want 0", version, err)
+	}
+	pending, err := db.Migrate(true)
+	if err != nil || len(pending) == 0 {
+		t.Fatalf("got %d pending migrations, %v", len(pending), err)
+	}
+	if _, err := db.Session.Exec("SELECT version FROM schema_version"); err == nil {
+		t.Fatal("schema_version was created by a dry run")
+	}
+	if _, err := db.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+	version, err = db.SchemaVersion()
+	if want := pending[len(pending)-1].Version; err != nil || version != want {
+		t.Fatalf("got version %d, %v, want %d", version, err, want)
+	}
+}
+
+func TestMigrateKeepsLegacyPoints(t *testing.T) {
+	db := openStorage(t, log.New(ioutil.Discard, "", 0), &hs.Config{})
+	t.Cleanup(func() { db.Close() })
+	var dir = "sqlite"
+	if db.Cfg.DBDriver == "postgres" {
+		dir = "postgres"
+	}
+	initial, err := os.ReadFile(filepath.Join("migrations", dir, "0001_initial.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Points as the schema before first_seen and players stored them
+	for _, query := range []string{
+		string(initial),
+		`CREATE TABLE schema_version (version INTEGER PRIMARY KEY, name TEXT NOT NULL, "timestamp" BIGINT NOT NULL)`,
+		`INSERT INTO schema_version (version, name, "timestamp") VALUES (1, 'initial', 0)`,
+		`INSERT INTO standard ("timestamp", "seasonId", region, name, rank) VALUES
+			(100, 105, 'US', 'a', 2), (200, 105, 'US', 'a', 1), (300, 105, 'US', 'a', 1),
+			(100, 105, 'US', 'b', 1), (200, 105, 'US', 'b', 2), (300, 105, 'EU', 'a', 5)`,
+		`INSERT INTO battlegrounds ("timestamp", "seasonId", region, name, rank, rating) VALUES
+			(100, 6, 'US', 'a', 1, 5000), (200, 6, 'US', 'a', 1, 5000), (300, 6, 'US', 'a', 1, 5100)`,
+	} {
+		if _, err := db.Session.Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Migrate(false); err != nil {
+		t.Fatal(err)
+	}
+
+	type seen struct {
+		region      string
+		name        string
+		first, last int64
+	}
+	cases := map[string][]seen{
+		"standard": {
+			{"US", "a", 100, 100}, {"US", "a", 200, 200}, {"US", "a", 200, 300},
+			{"US", "b", 100, 100}, {"US", "b", 200, 200}, {"EU", "a", 300, 300},
+		},
+		"battlegrounds": {
+			{"US", "a", 100, 100}, {"US", "a", 100, 200}, {"US", "a", 300, 300},
+		},
+	}
+	for table, want := range cases {
+		var got = make([]seen, 0)
+		err := db.Points(table, hs.PointFilter{}, func(p *hs.Point) error {
+			got = append(got, seen{p.Region, p.Name, p.FirstSeen, p.Timestamp})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", table, got, want)
+		}
+		var missing int
+		if err := db.Session.QueryRow("SELECT COUNT(*) FROM " + table + " WHERE player_id IS NULL").Scan(&missing); err != nil || missing != 0 {
+			t.Errorf("%s: got %d points without player, %v", table, missing, err)
+		}
+	}
+	// Players are shared by the modes of a region
+	if n := count(t, db, "players"); n != 3 {
+		t.Fatalf("got %d players, want 3", n)
+	}
+}